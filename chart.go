@@ -0,0 +1,281 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math"
+
+	"github.com/slack-go/slack"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// Size of the chart images, in pixels
+const chartWidth = 800
+const chartHeight = 400
+
+// Space around the plot for the title, axis labels and legend
+const (
+	chartMarginTop    = 32
+	chartMarginRight  = 24
+	chartMarginBottom = 28
+	chartMarginLeft   = 64
+	chartLegendHeight = 22
+)
+
+// Number of labels on each axis
+const chartYTicks = 5
+const chartXLabels = 6
+
+// Colors of the series, in order, and of the chart itself
+var (
+	chartPalette = []color.RGBA{
+		{0x33, 0x66, 0xcc, 0xff},
+		{0xdd, 0x33, 0x33, 0xff},
+		{0x00, 0xaf, 0x89, 0xff},
+		{0xff, 0xcc, 0x33, 0xff},
+		{0x7f, 0x4a, 0xb8, 0xff},
+		{0x72, 0x77, 0x7d, 0xff},
+	}
+	chartBackground = color.RGBA{0xff, 0xff, 0xff, 0xff}
+	chartGrid       = color.RGBA{0xea, 0xec, 0xf0, 0xff}
+	chartAxis       = color.RGBA{0xa2, 0xa9, 0xb1, 0xff}
+	chartText       = color.RGBA{0x20, 0x21, 0x22, 0xff}
+)
+
+// chartSeries is a named line in a chart
+type chartSeries struct {
+	label  string
+	values []float64
+}
+
+// lineChart is a chart of one or more series over the same labels
+type lineChart struct {
+	title  string
+	labels []string
+	series []chartSeries
+}
+
+// Output the chart as a PNG image
+func (c lineChart) png() ([]byte, error) {
+	if len(c.labels) == 0 || len(c.series) == 0 {
+		return nil, errors.New("chart has no values")
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, chartWidth, chartHeight))
+	draw.Draw(img, img.Bounds(), image.NewUniform(chartBackground), image.Point{}, draw.Src)
+
+	bottom := chartHeight - chartMarginBottom
+	if len(c.series) > 1 {
+		bottom -= chartLegendHeight
+	}
+	plot := image.Rect(chartMarginLeft, chartMarginTop, chartWidth-chartMarginRight, bottom)
+
+	drawChartText(img, chartMarginLeft, 20, c.title, chartText)
+
+	// Horizontal grid lines with their values
+	maxValue := niceCeiling(c.maxValue())
+	for tick := 0; tick <= chartYTicks; tick++ {
+		value := maxValue * float64(tick) / chartYTicks
+		y := plot.Max.Y - int(float64(plot.Dy())*float64(tick)/chartYTicks)
+		lineColor := chartGrid
+		if tick == 0 {
+			lineColor = chartAxis
+		}
+		drawChartLine(img, plot.Min.X, y, plot.Max.X, y, lineColor, 1)
+		label := compactNumber(value)
+		drawChartText(img, plot.Min.X-8-textWidth(label), y+4, label, chartText)
+	}
+
+	// A few labels along the bottom, spread evenly
+	step := 1
+	if len(c.labels) > chartXLabels {
+		step = int(math.Ceil(float64(len(c.labels)-1) / float64(chartXLabels-1)))
+	}
+	for i := 0; i < len(c.labels); i += step {
+		x := c.pointX(plot, i)
+		drawChartLine(img, x, plot.Max.Y, x, plot.Max.Y+4, chartAxis, 1)
+		left := x - textWidth(c.labels[i])/2
+		if left < 0 {
+			left = 0
+		}
+		if left+textWidth(c.labels[i]) > chartWidth {
+			left = chartWidth - textWidth(c.labels[i])
+		}
+		drawChartText(img, left, plot.Max.Y+18, c.labels[i], chartText)
+	}
+
+	for s, series := range c.series {
+		lineColor := chartPalette[s%len(chartPalette)]
+		pointY := func(value float64) int {
+			return plot.Max.Y - int(float64(plot.Dy())*value/maxValue)
+		}
+		for i := range series.values {
+			if i == 0 {
+				if len(series.values) == 1 {
+					drawChartLine(img, c.pointX(plot, 0)-2, pointY(series.values[0]), c.pointX(plot, 0)+2, pointY(series.values[0]), lineColor, 2)
+				}
+				continue
+			}
+			drawChartLine(img, c.pointX(plot, i-1), pointY(series.values[i-1]), c.pointX(plot, i), pointY(series.values[i]), lineColor, 2)
+		}
+	}
+
+	// The legend is only needed to tell several lines apart
+	if len(c.series) > 1 {
+		x := plot.Min.X
+		y := chartHeight - chartLegendHeight + 2
+		for s, series := range c.series {
+			swatch := image.Rect(x, y-9, x+10, y+1)
+			draw.Draw(img, swatch, image.NewUniform(chartPalette[s%len(chartPalette)]), image.Point{}, draw.Src)
+			drawChartText(img, x+14, y, series.label, chartText)
+			x += 14 + textWidth(series.label) + 20
+		}
+	}
+
+	output := bytes.Buffer{}
+	if err := png.Encode(&output, img); err != nil {
+		return nil, err
+	}
+	return output.Bytes(), nil
+}
+
+// Output the largest value of all series
+func (c lineChart) maxValue() (max float64) {
+	for _, series := range c.series {
+		for _, value := range series.values {
+			max = math.Max(max, value)
+		}
+	}
+	return max
+}
+
+// Output the horizontal position of the point with the given index
+func (c lineChart) pointX(plot image.Rectangle, index int) int {
+	if len(c.labels) < 2 {
+		return plot.Min.X + plot.Dx()/2
+	}
+	return plot.Min.X + int(float64(plot.Dx())*float64(index)/float64(len(c.labels)-1))
+}
+
+// Output a round number at least as big as the value, so the axis labels
+// are easy to read, like 5000 for 4321
+func niceCeiling(value float64) float64 {
+	if value <= 0 {
+		return 1
+	}
+	magnitude := math.Pow(10, math.Floor(math.Log10(value)))
+	for _, step := range []float64{1, 2, 2.5, 5, 10} {
+		if value <= step*magnitude {
+			return step * magnitude
+		}
+	}
+	return 10 * magnitude
+}
+
+// Format a number in a few characters, like "1.5k" or "12M"
+func compactNumber(value float64) string {
+	for _, unit := range []struct {
+		size   float64
+		suffix string
+	}{{1e9, "B"}, {1e6, "M"}, {1e3, "k"}} {
+		if value >= unit.size {
+			value /= unit.size
+			if value < 10 && value != math.Trunc(value) {
+				return fmt.Sprintf("%.1f%s", value, unit.suffix)
+			}
+			return fmt.Sprintf("%.0f%s", value, unit.suffix)
+		}
+	}
+	if value != math.Trunc(value) {
+		return fmt.Sprintf("%.1f", value)
+	}
+	return fmt.Sprintf("%.0f", value)
+}
+
+// Output the width of text in the chart font, in pixels
+func textWidth(text string) int {
+	return font.MeasureString(basicfont.Face7x13, text).Round()
+}
+
+// Draw text with its baseline at the given position. The font only has
+// ASCII characters; others are left out.
+func drawChartText(img draw.Image, x int, y int, text string, textColor color.Color) {
+	drawer := font.Drawer{
+		Dst:  img,
+		Src:  image.NewUniform(textColor),
+		Face: basicfont.Face7x13,
+		Dot:  fixed.P(x, y),
+	}
+	drawer.DrawString(text)
+}
+
+// Draw a straight line of the given thickness between two points
+func drawChartLine(img draw.Image, x0 int, y0 int, x1 int, y1 int, lineColor color.Color, thickness int) {
+	dx, dy := absInt(x1-x0), -absInt(y1-y0)
+	sx, sy := 1, 1
+	if x0 > x1 {
+		sx = -1
+	}
+	if y0 > y1 {
+		sy = -1
+	}
+	err := dx + dy
+	for {
+		for tx := 0; tx < thickness; tx++ {
+			for ty := 0; ty < thickness; ty++ {
+				img.Set(x0+tx, y0+ty, lineColor)
+			}
+		}
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		e2 := 2 * err
+		if e2 >= dy {
+			err += dy
+			x0 += sx
+		}
+		if e2 <= dx {
+			err += dx
+			y0 += sy
+		}
+	}
+}
+
+// Output the absolute value of an integer
+func absInt(value int) int {
+	if value < 0 {
+		return -value
+	}
+	return value
+}
+
+// Upload a chart to the thread of the message that asked for it
+func uploadChart(client *slack.Client, event *slack.MessageEvent, chart lineChart, title string) {
+	data, err := chart.png()
+	if err != nil {
+		fmt.Printf("Could not draw the chart %s: %v\n", title, err)
+		return
+	}
+	threadTimestamp := event.ThreadTimestamp
+	if len(threadTimestamp) == 0 {
+		threadTimestamp = event.EventTimestamp
+	}
+	_, err = client.UploadFile(slack.FileUploadParameters{
+		Reader:          bytes.NewReader(data),
+		Filetype:        "png",
+		Filename:        "pageviews.png",
+		Title:           title,
+		Channels:        []string{event.Channel},
+		ThreadTimestamp: threadTimestamp,
+	})
+	if err != nil {
+		fmt.Printf("Could not upload the chart %s: %v\n", title, err)
+	}
+}
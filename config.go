@@ -0,0 +1,132 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/mooeypoo/slack-wikipedia/wikipedia"
+)
+
+// Config is the optional bot configuration, read from the JSON file
+// given in the WIKIBOT_CONFIG environment variable
+type Config struct {
+	// Wikis are additional MediaWiki installations, selectable with wiki=name
+	Wikis map[string]*wikipedia.MediaWiki `json:"wikis"`
+	// Zims are local Kiwix ZIM archives, selectable with wiki=name
+	Zims map[string]zimConfig `json:"zims"`
+	// DefaultWiki is the name of the wiki used when a request doesn't
+	// ask for a wiki or a language. Defaults to the English Wikipedia.
+	DefaultWiki string `json:"defaultWiki"`
+	// Fallbacks are the wikis to retry in when "get" finds nothing, by
+	// channel ID. The "default" key applies to all other channels.
+	Fallbacks map[string][]string `json:"fallbacks"`
+	// TeamLanguages are the language codes the "langs" command
+	// highlights, and can filter its list down to
+	TeamLanguages []string `json:"teamLanguages"`
+	// DisableLanguageDetection turns off guessing the wiki language from
+	// the query when it has no lang= or wiki=
+	DisableLanguageDetection bool `json:"disableLanguageDetection"`
+	// FactsCard adds the Wikidata facts card to "get" results by default.
+	// Requests can override it with facts=yes or facts=no.
+	FactsCard bool `json:"factsCard"`
+	// FactProperties are the Wikidata properties on the facts card, by the
+	// item's "instance of" Q-id, or "default" for all other items
+	FactProperties map[string][]string `json:"factProperties"`
+	// InfoboxCard adds the article's infobox fields to "get" results by
+	// default. Requests can override it with infobox=yes or infobox=no.
+	InfoboxCard bool `json:"infoboxCard"`
+	// MetadataPanel adds the last edit, size, protection and a permanent
+	// link to "get" results by default. Requests can override it with
+	// meta=yes or meta=no.
+	MetadataPanel bool `json:"metadataPanel"`
+	// InteractionsAddress is the address, like ":3000", of the HTTP server
+	// that receives button clicks from Slack. Buttons are only shown when
+	// it is set, along with the SLACK_SIGNING_SECRET environment variable.
+	InteractionsAddress string `json:"interactionsAddress"`
+	// TopDenylist are titles left out of the "top" lists, like pages that
+	// bots inflate, by language code. The "default" key applies to all
+	// languages.
+	TopDenylist map[string][]string `json:"topDenylist"`
+}
+
+// zimConfig is the configuration of a single offline ZIM archive
+type zimConfig struct {
+	Name        string `json:"name"`
+	Path        string `json:"path"`
+	Lang        string `json:"lang"`
+	ArticlePath string `json:"articlePath"`
+}
+
+// Read the configuration file, if one was given.
+// An empty path outputs the default configuration.
+func loadConfig(path string) (config Config, err error) {
+	if len(strings.TrimSpace(path)) == 0 {
+		return config, nil
+	}
+
+	body, err := ioutil.ReadFile(path)
+	if err != nil {
+		return config, err
+	}
+	if err := json.Unmarshal(body, &config); err != nil {
+		return config, fmt.Errorf("invalid config file %s: %v", path, err)
+	}
+	return config, nil
+}
+
+// Output the fallback wikis for requests made in the given channel
+func (config Config) fallbacksFor(channel string) []string {
+	if fallbacks, ok := config.Fallbacks[channel]; ok {
+		return fallbacks
+	}
+	return config.Fallbacks["default"]
+}
+
+// Output the titles left out of the "top" lists of the given wiki
+func (config Config) topDenylistFor(wiki wikipedia.Backend) []string {
+	return append(append([]string{}, config.TopDenylist["default"]...), config.TopDenylist[wiki.Language()]...)
+}
+
+// Make the configured wikis available to the wikipedia package
+func registerWikis(config Config) {
+	registered := map[string]wikipedia.Backend{}
+
+	for name, wiki := range config.Wikis {
+		if len(wiki.BaseURL) == 0 && len(wiki.ActionAPI) == 0 {
+			fmt.Printf("Skipping wiki \"%s\": it needs a baseURL or an actionAPI\n", name)
+			continue
+		}
+		if len(wiki.DisplayName) == 0 {
+			wiki.DisplayName = name
+		}
+		registered[name] = wiki
+	}
+
+	for name, zim := range config.Zims {
+		if len(zim.Name) == 0 {
+			zim.Name = name
+		}
+		archive, err := wikipedia.OpenZIM(zim.Path, zim.Name, zim.Lang)
+		if err != nil {
+			fmt.Printf("Skipping ZIM archive \"%s\": %v\n", name, err)
+			continue
+		}
+		archive.ArticlePath = zim.ArticlePath
+		registered[name] = archive
+	}
+
+	for name, wiki := range registered {
+		wikipedia.RegisterWiki(name, wiki)
+		fmt.Printf("Registered wiki \"%s\" (%s)\n", name, wiki.Name())
+	}
+
+	if len(config.DefaultWiki) != 0 {
+		if wiki, ok := registered[config.DefaultWiki]; ok {
+			wikipedia.SetDefaultWiki(wiki)
+		} else {
+			fmt.Printf("Default wiki \"%s\" is not configured; using Wikipedia\n", config.DefaultWiki)
+		}
+	}
+}
@@ -4,8 +4,12 @@ go 1.14
 
 require (
 	github.com/araddon/dateparse v0.0.0-20200409225146-d820a6159ab1
+	github.com/klauspost/compress v1.11.4
 	github.com/shomali11/slacker v0.0.0-20200420173605-4887ab8127b6
 	github.com/slack-go/slack v0.6.5
+	github.com/ulikunitz/xz v0.5.11
+	golang.org/x/image v0.0.0-20200430140353-33d19683fad8
+	golang.org/x/net v0.0.0-20200421231249-e086a090c8fd
 )
 
 replace github.com/golang/lint => golang.org/x/lint v0.0.0-20200302205851-738671d3881b
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+
+	"github.com/mooeypoo/slack-wikipedia/wikipedia"
+	"github.com/slack-go/slack"
+)
+
+// The path Slack sends button clicks to
+const interactionsPath = "/slack/interactions"
+
+// The action ID of the "show" buttons of the outline command
+const showSectionActionID = "show_section"
+
+// sectionButtonValue is what a "show" button carries, to look the
+// section up again when it is clicked
+type sectionButtonValue struct {
+	Title string `json:"title"`
+	// Wiki is the wiki=name or lang=xx parameter of the article's wiki
+	Wiki  string `json:"wiki"`
+	Index string `json:"index"`
+}
+
+// Start the HTTP server that receives the clicks on message buttons.
+// Requests that aren't signed with the app's signing secret are rejected.
+func startInteractionsServer(address string, signingSecret string, client *slack.Client) {
+	mux := http.NewServeMux()
+	mux.HandleFunc(interactionsPath, func(w http.ResponseWriter, r *http.Request) {
+		verifier, err := slack.NewSecretsVerifier(r.Header, signingSecret)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		body, err := ioutil.ReadAll(io.TeeReader(r.Body, &verifier))
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if err := verifier.Ensure(); err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		values, err := url.ParseQuery(string(body))
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		callback := slack.InteractionCallback{}
+		if err := json.Unmarshal([]byte(values.Get("payload")), &callback); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		// Slack expects an answer within a few seconds, so the
+		// actions are handled after acknowledging them
+		w.WriteHeader(http.StatusOK)
+		for _, action := range callback.ActionCallback.BlockActions {
+			if action.ActionID == showSectionActionID {
+				go postSection(client, callback, action.Value)
+			}
+		}
+	})
+
+	go func() {
+		fmt.Printf("Listening for interactions on %s%s\n", address, interactionsPath)
+		log.Fatal(http.ListenAndServe(address, mux))
+	}()
+}
+
+// Post the section of a clicked "show" button into the thread of the
+// message that has the button
+func postSection(client *slack.Client, callback slack.InteractionCallback, buttonValue string) {
+	value := sectionButtonValue{}
+	if err := json.Unmarshal([]byte(buttonValue), &value); err != nil {
+		fmt.Printf("Invalid section button value: %s\n", buttonValue)
+		return
+	}
+
+	page, wiki, found := wikipedia.FetchSectionByIndex(value.Title+" "+value.Wiki, value.Index)
+	attachments := getFullReplyAttachments(value.Title, fmt.Sprintf("<@%s> asked for this section of \"*%s*\" on %s:", callback.User.ID, wikipedia.EscapeMrkdwn(value.Title), wiki.Name()), []wikipedia.Page{page}, wiki)
+	if !found {
+		attachments = getResultListHeader(fmt.Sprintf("Sorry, I couldn't find that section of \"*%s*\" anymore.", wikipedia.EscapeMrkdwn(value.Title)))
+	}
+
+	thread := callback.Message.ThreadTimestamp
+	if len(thread) == 0 {
+		thread = callback.Message.Timestamp
+	}
+	_, _, err := client.PostMessage(callback.Channel.ID,
+		slack.MsgOptionText(page.Title, false),
+		slack.MsgOptionBlocks(attachments...),
+		slack.MsgOptionTS(thread))
+	if err != nil {
+		fmt.Printf("Could not post the section: %v\n", err)
+	}
+}
+
+// Encode the value of a "show" button for a section
+func getSectionButtonValue(title string, wiki wikipedia.Backend, section wikipedia.Section) string {
+	value, _ := json.Marshal(sectionButtonValue{title, wikipedia.WikiParameter(wiki), section.Index})
+	return string(value)
+}
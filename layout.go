@@ -0,0 +1,186 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/shomali11/slacker"
+	"github.com/slack-go/slack"
+)
+
+// Slack's maximum length of the text of a section block
+const sectionTextLimit = 3000
+
+// Slack's maximum length of a section field
+const fieldTextLimit = 2000
+
+// Slack's maximum number of blocks in a message
+const messageBlocksLimit = 50
+
+// The lengths extracts are shortened to in lists of results
+const listExtractLimit = 150
+const sideBySideExtractLimit = 300
+
+// Added to text that was shortened
+const truncatedSuffix = " [...]"
+
+// The end of a sentence, with any closing quotes or brackets. Full-width
+// stops don't need a space after them.
+var sentenceEndRegexp = regexp.MustCompile(`[.!?]["'”’)\]]*(?:\s|$)|[。！？]["'”’)\]]*`)
+
+// A <url|label> link, or a <url> without a label
+var linkRegexp = regexp.MustCompile(`<([^>|]*)(?:\|([^>]*))?>`)
+
+// The bold and italic markers that closeFormatting closes
+var formattingMarkers = []string{"*", "_"}
+
+// Shorten text to at most limit characters, ending at a paragraph,
+// sentence or word where possible. Room is kept for the markers of the
+// formatting the cut leaves open.
+func truncateText(text string, limit int) string {
+	if utf8.RuneCountInString(text) <= limit {
+		return text
+	}
+	index, text := breakPoint(text, limit-utf8.RuneCountInString(truncatedSuffix)-len(formattingMarkers))
+	return closeFormatting(strings.TrimSpace(text[:index])) + truncatedSuffix
+}
+
+// Split text into chunks of at most limit characters each, breaking at
+// paragraphs, sentences or words where possible
+func splitText(text string, limit int) []string {
+	chunks := []string{}
+	for utf8.RuneCountInString(text) > limit {
+		index, fitted := breakPoint(text, limit)
+		if chunk := strings.TrimSpace(fitted[:index]); len(chunk) != 0 {
+			chunks = append(chunks, chunk)
+		}
+		text = strings.TrimSpace(fitted[index:])
+	}
+	if len(text) != 0 {
+		chunks = append(chunks, text)
+	}
+	return chunks
+}
+
+// Output the byte index where text should be cut to be at most limit
+// characters long. Links and escaped characters are never cut in the
+// middle: a link at the start that is too long to fit is replaced by its
+// label, and fitted is the text with that change.
+func breakPoint(text string, limit int) (index int, fitted string) {
+	for {
+		end := runeEnd(text, limit)
+		if index = safeBreakPoint(text, end); index > 0 {
+			return index, text
+		}
+		match := linkRegexp.FindStringSubmatchIndex(text)
+		if match == nil || match[0] != 0 {
+			return end, text
+		}
+		label := text[match[2]:match[3]]
+		if match[4] >= 0 && match[5] > match[4] {
+			label = text[match[4]:match[5]]
+		}
+		text = label + text[match[1]:]
+	}
+}
+
+// Output the byte index after the first limit characters of text
+func runeEnd(text string, limit int) int {
+	end := 0
+	for count := 0; end < len(text) && count < limit; count++ {
+		_, size := utf8.DecodeRuneInString(text[end:])
+		end += size
+	}
+	return end
+}
+
+// Output the byte index, up to end, where text should be cut. The latest
+// paragraph, line, sentence or word break is used, if it keeps at least
+// half of the text. The output is 0 if nothing fits without cutting a link
+// or an escaped character.
+func safeBreakPoint(text string, end int) int {
+	window := text[:end]
+	if end == len(text) {
+		return end
+	}
+
+	// Don't cut inside a <link|title> or an &amp; entity
+	if open := strings.LastIndex(window, "<"); open > strings.LastIndex(window, ">") {
+		window = window[:open]
+	}
+	if amp := strings.LastIndex(window, "&"); amp > strings.LastIndex(window, ";") && len(window)-amp < 8 {
+		window = window[:amp]
+	}
+
+	finders := []func(window string) int{
+		func(window string) int { return strings.LastIndex(window, "\n\n") },
+		func(window string) int { return strings.LastIndex(window, "\n") },
+		func(window string) int {
+			matches := sentenceEndRegexp.FindAllStringIndex(window, -1)
+			if len(matches) == 0 {
+				return -1
+			}
+			return matches[len(matches)-1][1]
+		},
+		func(window string) int { return strings.LastIndexAny(window, " \t") },
+	}
+	for _, find := range finders {
+		if index := find(window); index > 0 && index >= len(window)/2 {
+			return index
+		}
+	}
+	return len(window)
+}
+
+// Close a bold or italic marker that a cut left open
+func closeFormatting(text string) string {
+	// Link targets have underscores that aren't formatting
+	outsideLinks := linkRegexp.ReplaceAllString(text, "")
+	for _, marker := range formattingMarkers {
+		if strings.Count(outsideLinks, marker)%2 == 1 && !strings.HasSuffix(text, marker) {
+			text += marker
+		}
+	}
+	return text
+}
+
+// Split the given lines into as few section blocks as Slack allows.
+// Lines too long for a single block are split too.
+func getTextSections(lines []string) (att []slack.Block) {
+	attachments := []slack.Block{}
+	current := ""
+	for _, line := range lines {
+		for _, chunk := range splitText(line, sectionTextLimit) {
+			if utf8.RuneCountInString(current)+utf8.RuneCountInString(chunk)+1 > sectionTextLimit {
+				attachments = append(attachments, slack.NewSectionBlock(slack.NewTextBlockObject("mrkdwn", current, false, false), nil, nil))
+				current = ""
+			}
+			if len(current) != 0 {
+				current += "\n"
+			}
+			current += chunk
+		}
+	}
+	if len(current) != 0 {
+		attachments = append(attachments, slack.NewSectionBlock(slack.NewTextBlockObject("mrkdwn", current, false, false), nil, nil))
+	}
+	return attachments
+}
+
+// Split the blocks into groups small enough for a single message
+func splitBlocks(blocks []slack.Block, size int) [][]slack.Block {
+	groups := [][]slack.Block{}
+	for len(blocks) > size {
+		groups = append(groups, blocks[:size])
+		blocks = blocks[size:]
+	}
+	return append(groups, blocks)
+}
+
+// Reply with the given blocks, in as many messages as Slack needs
+func replyWithBlocks(response slacker.ResponseWriter, text string, blocks []slack.Block, inThread bool) {
+	for _, group := range splitBlocks(blocks, messageBlocksLimit) {
+		response.Reply(text, slacker.WithBlocks(group), slacker.WithThreadReply(inThread))
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"unicode/utf8"
+)
+
+func Test_truncateText(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		limit    int
+		expected string
+	}{
+		{"Exact limit", "Twenty characters!!!", 20, "Twenty characters!!!"},
+		{"Sentence", "Paris is the capital of France. It is big and old and lovely.", 40, "Paris is the capital of France. [...]"},
+		{"Cut inside a link", "See <https://example.com/a_b|the page about it> for more", 30, "See [...]"},
+		{"Link too long to fit", "<https://example.com/a/very/long/link|A long label for the link> and more", 20, "A long [...]"},
+		{"Cut inside an entity", "Fish &amp; chips &amp; peas &amp; more", 20, "Fish &amp; [...]"},
+		{"Open bold", "*Bold text that goes on for quite a while* and then some", 25, "*Bold text that* [...]"},
+		{"Open italics", "_Italic and *bold text that goes on_ for quite a while* and then some", 25, "_Italic and_ [...]"},
+		{"Open bold without a break", "*Boldtextthatgoesonforquiteawhile*", 20, "*Boldtexttha* [...]"},
+		{"Open bold and italics without a break", "*_Boldtextthatgoesonforquiteawhile_*", 20, "*_Boldtextth*_ [...]"},
+		{"Multibyte text", "東京は日本の首都です。大阪は日本の都市です。京都も日本の都市です。", 20, "東京は日本の首都です。 [...]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			truncated := truncateText(tt.text, tt.limit)
+			if truncated != tt.expected {
+				t.Errorf("truncateText() = %q, want %q", truncated, tt.expected)
+			}
+			if utf8.RuneCountInString(truncated) > tt.limit || !utf8.ValidString(truncated) {
+				t.Errorf("truncateText() = %q, longer than %d characters or not valid UTF-8", truncated, tt.limit)
+			}
+		})
+	}
+}
+
+func Test_splitText(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		limit    int
+		expected []string
+	}{
+		{"Exact limit", "Twenty characters!!!", 20, []string{"Twenty characters!!!"}},
+		{"Sentences", "First sentence here. Second sentence here. Third one.", 25, []string{"First sentence here.", "Second sentence here.", "Third one."}},
+		{"Link too long to fit", "<https://example.com/a/very/long/link|A long label> and more text here", 20, []string{"A long label and", "more text here"}},
+		{"Link kept whole", "Read <https://a.io|this> and that", 20, []string{"Read", "<https://a.io|this>", "and that"}},
+		{"Multibyte text", "日本語の文章です。日本語の文章です。日本語の文章です。", 10, []string{"日本語の文章です。", "日本語の文章です。", "日本語の文章です。"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if chunks := splitText(tt.text, tt.limit); !reflect.DeepEqual(chunks, tt.expected) {
+				t.Errorf("splitText() = %q, want %q", chunks, tt.expected)
+			}
+		})
+	}
+}
+
+func Test_closeFormatting(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		expected string
+	}{
+		{"Closed", "*bold* and _italics_", "*bold* and _italics_"},
+		{"Open bold", "*bold and", "*bold and*"},
+		{"Open italics and bold", "_italics and *bold", "_italics and *bold*_"},
+		{"Underscores in a link", "<https://example.com/a_b|link> and _more", "<https://example.com/a_b|link> and _more_"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if closed := closeFormatting(tt.text); closed != tt.expected {
+				t.Errorf("closeFormatting() = %q, want %q", closed, tt.expected)
+			}
+		})
+	}
+}
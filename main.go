@@ -5,7 +5,11 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"regexp"
 	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/mooeypoo/slack-wikipedia/wikipedia"
 	"github.com/shomali11/slacker"
@@ -14,10 +18,32 @@ import (
 
 const resultsLimit = 3
 
+// The number of articles in "top" lists
+const topResultsLimit = 10
+
+// How many of the days missing from a total are listed in a reply
+const missingDaysListed = 5
+
+// How long to wait for all wikis when looking up several languages at once
+const multipleLanguagesDeadline = 4 * time.Second
+
 func main() {
 	token := os.Getenv("SLACK_TOKEN")
+	config, err := loadConfig(os.Getenv("WIKIBOT_CONFIG"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	registerWikis(config)
+
 	bot := slacker.NewClient(token)
 	fmt.Println("Bot connected.")
+
+	// Buttons need an HTTP endpoint for Slack to send the clicks to
+	signingSecret := os.Getenv("SLACK_SIGNING_SECRET")
+	interactive := len(config.InteractionsAddress) != 0 && len(signingSecret) != 0
+	if interactive {
+		startInteractionsServer(config.InteractionsAddress, signingSecret, bot.Client())
+	}
 	// defSummary := &slacker.CommandDefinition{
 	// 	Description: "Get the summary of the given page.",
 	// 	Example:     "summary san francisco international airport",
@@ -53,82 +79,108 @@ func main() {
 			response.Typing()
 
 			text := request.StringParam("text", "")
-			results, lang, strippedText := wikipedia.FetchSearch(text)
+			detectedLang, detectionNote := detectLanguage(text, config)
+			results, wiki, strippedText := wikipedia.FetchSearch(withDetectedLanguage(text, detectedLang))
 
-			attachments := getFullReplyAttachments(strippedText, fmt.Sprintf("Here's what I found for \"*%s*\" on %s.Wikipedia:", strippedText, lang), results, lang)
-			response.Reply(text, slacker.WithBlocks(attachments), slacker.WithThreadReply(true))
+			attachments := getFullReplyAttachments(strippedText, fmt.Sprintf("Here's what I found for \"*%s*\" on %s:", wikipedia.EscapeMrkdwn(strippedText), wiki.Name()), results, wiki)
+			attachments = append(attachments, getNoteAttachments(detectionNote)...)
+			replyWithBlocks(response, text, attachments, true)
 		},
 	}
 
 	defTopviews := &slacker.CommandDefinition{
-		Description: "See top viewed articles for the given date. Provide no date to see today's results.",
+		Description: "See top viewed articles for the given date or range of dates, like \"last week\", \"March 2020\" or \"2020-03-01..2020-03-07\". Provide no date to see today's results. Add \"country=DE\" or \"access=mobile-web\" to narrow the list down.",
 		Example:     "top March 1 2020",
 		Handler: func(request slacker.Request, response slacker.ResponseWriter) {
 			response.Typing()
 
 			text := request.StringParam("text", "")
-			lang, strippedText := wikipedia.ParseLanguageFromText(text)
-			actualRequestedTime := wikipedia.ParseTimeString(strippedText)
+			wiki, strippedText := wikipedia.ParseWikiFromText(text)
+			options, strippedText := wikipedia.ParseTopPageviewsOptions(strippedText)
+			requestedRange, isRange, err := wikipedia.ParseDateOrRange(strippedText, userNow(bot.Client(), request.Event().User))
+			if err != nil {
+				replyWithBlocks(response, text, getDateErrorAttachments(strippedText, err), true)
+				return
+			}
 
 			// Build output
 			attachments := []slack.Block{}
 
-			if !wikipedia.IsDateBeforeUTCToday(actualRequestedTime) {
-				// We are asking for a date that is still "tomorrow" for UTC
-				// Change that to the previous day
-				newRequestedTime := actualRequestedTime.AddDate(0, 0, -1)
-				// Let the user know, but only if the user actually requested a date and not an empty string
-				if len(strings.TrimSpace(text)) != 0 {
-					humanReadableOrig := fmt.Sprintf("%s %02d %d", actualRequestedTime.Month(), actualRequestedTime.Day(), actualRequestedTime.Year())
-					humanReadableNew := fmt.Sprintf("%s %02d %d", newRequestedTime.Month(), newRequestedTime.Day(), newRequestedTime.Year())
-
-					switchDateText := slack.NewTextBlockObject("mrkdwn",
-						fmt.Sprintf("I don't have information yet for the top views on *%s*. Let's see if I can find any results for *%s* instead.", humanReadableOrig, humanReadableNew),
-						false, false)
-
-					switchDateSection := slack.NewSectionBlock(switchDateText, nil, nil)
-					attachments = append(attachments, switchDateSection)
-				}
-				actualRequestedTime = newRequestedTime
+			analyticsWiki, ok := wiki.(*wikipedia.MediaWiki)
+			if !ok || !analyticsWiki.Supports(wikipedia.FeaturePageviews) {
+				unsupportedText := slack.NewTextBlockObject("mrkdwn",
+					fmt.Sprintf("Sorry, I don't have pageview information for %s.", wiki.Name()),
+					false, false)
+				attachments = append(attachments, slack.NewSectionBlock(unsupportedText, nil, nil))
+				replyWithBlocks(response, text, attachments, true)
+				return
 			}
 
-			formattedRequestedTime := fmt.Sprintf("%s %02d %d", actualRequestedTime.Month(), actualRequestedTime.Day(), actualRequestedTime.Year())
+			var results []wikipedia.PagelistPage
+			notes := []string{}
+			formattedRequestedTime := requestedRange.String()
+			if isRange {
+				// Only the days that have data are added up, up to a year of them
+				availableRange, changed, found := wikipedia.PageviewsAvailability.AvailableRange(requestedRange, wikipedia.TopRangeMaxDays)
+				if !found {
+					replyWithBlocks(response, text, getResultListHeader(fmt.Sprintf("I don't have information yet for the top views on *%s*. :calendar:", formattedRequestedTime)), true)
+					return
+				}
+				if changed {
+					attachments = append(attachments, getChangedRangeAttachments(requestedRange, availableRange, "the top views")...)
+				}
+				formattedRequestedTime = availableRange.String()
+
+				var missing []wikipedia.DateRange
+				results, missing = analyticsWiki.TopPageviewsRange(availableRange, options)
+				if len(missing) != 0 {
+					notes = append(notes, getMissingDaysNote(missing))
+				}
+			} else {
+				actualRequestedTime, switchDateAttachments := getAvailableDay(wikipedia.PageviewsAvailability, requestedRange.Start, len(strings.TrimSpace(strippedText)) != 0, "the top views")
+				attachments = append(attachments, switchDateAttachments...)
+
+				formattedRequestedTime = fmt.Sprintf("%s %02d %d", actualRequestedTime.Month(), actualRequestedTime.Day(), actualRequestedTime.Year())
+
+				results = analyticsWiki.TopPageviews(actualRequestedTime, options)
+			}
 
-			results := wikipedia.FetchTopPageviews(formattedRequestedTime, lang)
+			// Only articles count towards the limit
+			results = wikipedia.FilterTopPageviews(results, analyticsWiki, config.topDenylistFor(wiki), topResultsLimit)
+			breakdown := ""
+			if labels := options.Labels(); len(labels) != 0 {
+				breakdown = " (" + strings.Join(labels, ", ") + ")"
+			}
 			fmt.Printf("Requested 'top' with parameter \"%s\" parsed into date \"%s\"\n", text, formattedRequestedTime)
 
 			if len(results) == 0 || results[0].Title == "" || results[0].Title == "Not found." {
 				notFoundText := slack.NewTextBlockObject("mrkdwn",
-					fmt.Sprintf("Oops, I couldn't find the top viewed articles in %s.Wikipedia for the date *\"%s\"*. :face_with_rolling_eyes: :grimacing:", lang, formattedRequestedTime),
+					fmt.Sprintf("Oops, I couldn't find the top viewed articles in %s%s for *\"%s\"*. :face_with_rolling_eyes: :grimacing:", wiki.Name(), breakdown, formattedRequestedTime),
 					false, false)
 				fmt.Println("Request for top views not found.")
 				headerSection := slack.NewSectionBlock(notFoundText, nil, nil)
 				attachments = append(attachments, headerSection)
 			} else {
 				fmt.Println("Request for top views found. Response being built.")
-				// Remove 'Main_Page' and 'Special:Search' from results
-				// And cut at 10 items
 				header := slack.NewSectionBlock(slack.NewTextBlockObject(
 					"mrkdwn",
-					fmt.Sprintf("Top viewed pages for *%s* on %s.Wikipedia", formattedRequestedTime, lang),
+					fmt.Sprintf("Top viewed pages for *%s* on %s%s", formattedRequestedTime, wiki.Name(), breakdown),
 					false, false),
 					nil, nil)
 				attachments = append(attachments, header)
 
 				for _, page := range results {
-					// if page.Title != "Main Page" && page.Title != "Special:Search" && len(attachments) < 10 {
-					if len(attachments) < 10 {
-						section := slack.NewSectionBlock(slack.NewTextBlockObject(
-							"mrkdwn",
-							fmt.Sprintf("*%d most viewed:* <%s|%s> (%s page views)", page.Rank, page.URL, page.Title, page.Info),
-							false, false),
-							nil, nil)
-						attachments = append(attachments, section)
-					}
+					section := slack.NewSectionBlock(slack.NewTextBlockObject(
+						"mrkdwn",
+						fmt.Sprintf("*%d most viewed:* %s (%s page views)", page.Rank, pageLink(page.URL, page.Title), page.Info),
+						false, false),
+						nil, nil)
+					attachments = append(attachments, section)
 				}
 			}
+			attachments = append(attachments, getNoteAttachments(strings.Join(notes, " "))...)
 			fmt.Printf("Sending response to Slack with %d attachments\n", len(attachments))
-			response.Reply(formattedRequestedTime, slacker.WithBlocks(attachments), slacker.WithThreadReply(true))
+			replyWithBlocks(response, formattedRequestedTime, attachments, true)
 		},
 	}
 
@@ -139,12 +191,82 @@ func main() {
 			response.Typing()
 
 			text := request.StringParam("text", "")
-			// results, related, lang, actualTitle := wikipedia.FetchGetGeneralTerm(text)
-			results, related, lang, actualTitle := wikipedia.FetchGetGeneralTerm(text)
+			withFacts, text := parseToggleFromText(text, "facts", config.FactsCard)
+			withInfobox, text := parseToggleFromText(text, "infobox", config.InfoboxCard)
+			withMetadata, text := parseToggleFromText(text, "meta", config.MetadataPanel)
+
+			// Several languages are looked up side by side
+			if langs, _ := wikipedia.ParseLanguagesFromText(text); len(langs) > 1 {
+				results, actualTitle := wikipedia.FetchGetGeneralTermMultiple(text, langs, multipleLanguagesDeadline)
+				attachments := getMultipleWikisReplyAttachments(actualTitle, results)
+				replyWithBlocks(response, text, attachments, true)
+				return
+			}
+
+			detectedLang, detectionNote := detectLanguage(text, config)
+
+			// A single section of the article, like "Influenza#Symptoms"
+			if _, _, hasSection := wikipedia.ParseSectionFromText(text); hasSection {
+				result, wiki, actualTitle, sectionName := wikipedia.FetchSection(withDetectedLanguage(text, detectedLang))
+				attachments := getSectionAttachments(result, wiki, actualTitle, sectionName)
+				attachments = append(attachments, getNoteAttachments(detectionNote)...)
+				replyWithBlocks(response, text, attachments, result.Section == nil)
+				return
+			}
+
+			fallbacks := config.fallbacksFor(request.Event().Channel)
+			results, related, wiki, actualTitle, fallback := wikipedia.FetchGetGeneralTermWithFallback(withDetectedLanguage(text, detectedLang), fallbacks)
+
+			headerText := fmt.Sprintf("Here's what I found for \"*%s*\" on %s:", wikipedia.EscapeMrkdwn(actualTitle), wiki.Name())
+			if fallback != nil {
+				headerText = fmt.Sprintf("I couldn't find \"*%s*\" on %s, but here's what I found on *%s*:", wikipedia.EscapeMrkdwn(actualTitle), fallback.Requested.Name(), wiki.Name())
+			}
 
 			// Get the response first; this will already return the correct
 			// format, whether it was summary or search list
-			attachments := getFullReplyAttachments(actualTitle, fmt.Sprintf("Here's what I found for \"*%s*\" on %s.Wikipedia:", actualTitle, lang), results, lang)
+			attachments := getFullReplyAttachments(actualTitle, headerText, results, wiki)
+
+			// Offer the article in the language that was requested, if it exists
+			if fallback != nil && len(fallback.LanguageLink.URL) != 0 {
+				attachments = append(attachments, slack.NewContextBlock("",
+					slack.NewTextBlockObject(
+						"mrkdwn",
+						fmt.Sprintf("This article is also available on %s: %s", fallback.Requested.Name(), pageLink(fallback.LanguageLink.URL, fallback.LanguageLink.Title)),
+						false, false)))
+			}
+			attachments = append(attachments, getNoteAttachments(detectionNote)...)
+
+			// Show where a single result is
+			if len(results) == 1 && results[0].Coordinates != nil {
+				attachments = append(attachments, getNoteAttachments(getLocationNote(*results[0].Coordinates))...)
+			}
+
+			// Add the Wikidata facts of a single result
+			if withFacts && len(results) == 1 && len(results[0].WikibaseItem) != 0 {
+				card, found := wikipedia.FetchFactsCard(results[0].WikibaseItem, wiki.Language(), config.FactProperties)
+				if found {
+					attachments = append(attachments, getFactsCardAttachments(card)...)
+				}
+			}
+
+			// Add the details of the current revision of a single result
+			if withMetadata && len(results) == 1 && results[0].Title != "Not found." {
+				if mediaWiki, ok := wiki.(*wikipedia.MediaWiki); ok {
+					if metadata, found := mediaWiki.Metadata(results[0].Title); found {
+						location := userLocation(bot.Client(), request.Event().User)
+						attachments = append(attachments, getNoteAttachments(getMetadataNote(metadata, location))...)
+					}
+				}
+			}
+
+			// Add the infobox of a single result; the image is already shown
+			if withInfobox && len(results) == 1 && results[0].Title != "Not found." {
+				if mediaWiki, ok := wiki.(*wikipedia.MediaWiki); ok {
+					if infobox, found := mediaWiki.Infobox(results[0].Title); found {
+						attachments = append(attachments, getInfoboxFieldsAttachments(infobox)...)
+					}
+				}
+			}
 
 			// Add related pages, if they exist
 			relatedTitles := []string{}
@@ -155,7 +277,7 @@ func main() {
 					if itemCount >= 5 {
 						break
 					}
-					relatedTitles = append(relatedTitles, fmt.Sprintf("<%s|%s>", item.URL, item.Title))
+					relatedTitles = append(relatedTitles, pageLink(item.URL, item.Title))
 					itemCount++
 				}
 			}
@@ -174,8 +296,227 @@ func main() {
 			// Check whether to deliver in a reply or not
 			inReply := len(results) > 1
 
-			response.Reply(text, slacker.WithBlocks(attachments), slacker.WithThreadReply(inReply))
+			replyWithBlocks(response, text, attachments, inReply)
+
+		},
+	}
+
+	defLangs := &slacker.CommandDefinition{
+		Description: "List the other languages an article is available in. Add filter=team to only see the team languages.",
+		Example:     "langs Tel Aviv",
+		Handler: func(request slacker.Request, response slacker.ResponseWriter) {
+			response.Typing()
+
+			text := request.StringParam("text", "")
+			onlyTeam := strings.Contains(text, "filter=team")
+			text = strings.TrimSpace(strings.ReplaceAll(text, "filter=team", ""))
+
+			detectedLang, detectionNote := detectLanguage(text, config)
+			links, page, wiki, actualTitle := wikipedia.FetchLanguageLinks(withDetectedLanguage(text, detectedLang))
+			attachments := getLanguageLinksAttachments(actualTitle, page, wiki, links, config.TeamLanguages, onlyTeam)
+			attachments = append(attachments, getNoteAttachments(detectionNote)...)
+			replyWithBlocks(response, text, attachments, true)
+		},
+	}
+
+	defFact := &slacker.CommandDefinition{
+		Description: "Get a single fact about something from Wikidata.",
+		Example:     "fact population of Tokyo",
+		Handler: func(request slacker.Request, response slacker.ResponseWriter) {
+			response.Typing()
+
+			text := request.StringParam("text", "")
+			detectedLang, detectionNote := detectLanguage(text, config)
+			answer, wiki, found := wikipedia.FetchFact(withDetectedLanguage(text, detectedLang))
+
+			attachments := getFactAnswerAttachments(text, answer, wiki, found)
+			attachments = append(attachments, getNoteAttachments(detectionNote)...)
+			replyWithBlocks(response, text, attachments, false)
+		},
+	}
+
+	defNearby := &slacker.CommandDefinition{
+		Description: "List the Wikipedia articles near a place or coordinates, with an optional radius.",
+		Example:     "nearby Eiffel Tower 2km",
+		Handler: func(request slacker.Request, response slacker.ResponseWriter) {
+			response.Typing()
+
+			text := request.StringParam("text", "")
+			detectedLang, detectionNote := detectLanguage(text, config)
+			results, center, place, radius, wiki := wikipedia.FetchNearby(withDetectedLanguage(text, detectedLang))
+
+			attachments := getNearbyAttachments(results, center, place, radius, wiki)
+			attachments = append(attachments, getNoteAttachments(detectionNote)...)
+			replyWithBlocks(response, text, attachments, true)
+		},
+	}
+
+	defOutline := &slacker.CommandDefinition{
+		Description: "List the sections of an article.",
+		Example:     "outline Influenza",
+		Handler: func(request slacker.Request, response slacker.ResponseWriter) {
+			response.Typing()
+
+			text := request.StringParam("text", "")
+			detectedLang, detectionNote := detectLanguage(text, config)
+			page, sections, wiki, actualTitle := wikipedia.FetchOutline(withDetectedLanguage(text, detectedLang))
+
+			attachments := getOutlineAttachments(actualTitle, page, sections, wiki, interactive)
+			attachments = append(attachments, getNoteAttachments(detectionNote)...)
+			replyWithBlocks(response, text, attachments, true)
+		},
+	}
+
+	defInfobox := &slacker.CommandDefinition{
+		Description: "Show the infobox of an article.",
+		Example:     "infobox Tokyo",
+		Handler: func(request slacker.Request, response slacker.ResponseWriter) {
+			response.Typing()
+
+			text := request.StringParam("text", "")
+			detectedLang, detectionNote := detectLanguage(text, config)
+			infobox, wiki, actualTitle, found := wikipedia.FetchInfobox(withDetectedLanguage(text, detectedLang))
+
+			attachments := getInfoboxAttachments(actualTitle, infobox, wiki, found)
+			attachments = append(attachments, getNoteAttachments(detectionNote)...)
+			replyWithBlocks(response, text, attachments, true)
+		},
+	}
+
+	defCite := &slacker.CommandDefinition{
+		Description: "Cite the current revision of an article. Styles: " + strings.Join(wikipedia.CitationStyles, ", ") + ".",
+		Example:     "cite Tokyo mla",
+		Handler: func(request slacker.Request, response slacker.ResponseWriter) {
+			response.Typing()
+
+			text := request.StringParam("text", "")
+			detectedLang, detectionNote := detectLanguage(text, config)
+			citation, style, wiki, actualTitle, found := wikipedia.FetchCitation(withDetectedLanguage(text, detectedLang))
+
+			attachments := getCitationAttachments(actualTitle, citation, style, wiki, found)
+			attachments = append(attachments, getNoteAttachments(detectionNote)...)
+			replyWithBlocks(response, text, attachments, true)
+		},
+	}
+
+	defViews := &slacker.CommandDefinition{
+		Description: "Show the pageviews of an article over a range of days, with a chart. Add \"monthly\", \"access=mobile-web\" or \"agent=all-agents\" to change what is counted.",
+		Example:     "views Tokyo last 90 days",
+		Handler: func(request slacker.Request, response slacker.ResponseWriter) {
+			response.Typing()
+
+			text := request.StringParam("text", "")
+			detectedLang, detectionNote := detectLanguage(text, config)
+			views, wiki, actualTitle, found := wikipedia.FetchArticlePageviews(withDetectedLanguage(text, detectedLang), userNow(bot.Client(), request.Event().User))
+
+			attachments := getPageviewsAttachments(actualTitle, views, wiki, found)
+			attachments = append(attachments, getNoteAttachments(detectionNote)...)
+			replyWithBlocks(response, text, attachments, true)
+
+			if found {
+				uploadChart(bot.Client(), request.Event(), getPageviewsChart(views), views.Page.Title+" pageviews")
+			}
+		},
+	}
+
+	defCompare := &slacker.CommandDefinition{
+		Description: "Compare the pageviews of several articles over a range of days, with a chart.",
+		Example:     "compare views Tokyo vs Osaka vs Kyoto last 90 days",
+		Handler: func(request slacker.Request, response slacker.ResponseWriter) {
+			response.Typing()
+
+			text := request.StringParam("text", "")
+			// Only pageviews can be compared for now
+			fields := strings.Fields(text)
+			if len(fields) == 0 || strings.ToLower(fields[0]) != "views" {
+				replyWithBlocks(response, text, getResultListHeader("Tell me what to compare, like `compare views Tokyo vs Osaka`."), true)
+				return
+			}
+			compared := strings.TrimSpace(text[strings.Index(text, fields[0])+len(fields[0]):])
+			detectedLang, detectionNote := detectLanguage(compared, config)
+			comparison, wiki, found := wikipedia.FetchPageviewsComparison(withDetectedLanguage(compared, detectedLang), userNow(bot.Client(), request.Event().User))
+
+			attachments := getComparisonAttachments(compared, comparison, wiki, found)
+			attachments = append(attachments, getNoteAttachments(detectionNote)...)
+			replyWithBlocks(response, text, attachments, true)
+
+			if found {
+				uploadChart(bot.Client(), request.Event(), getComparisonChart(comparison), "Pageviews comparison")
+			}
+		},
+	}
+
+	defTrending := &slacker.CommandDefinition{
+		Description: "See the articles people are suddenly reading, compared with the week before. Provide no date to see today's results.",
+		Example:     "trending March 1 2020",
+		Handler: func(request slacker.Request, response slacker.ResponseWriter) {
+			response.Typing()
+
+			text := request.StringParam("text", "")
+			wiki, strippedText := wikipedia.ParseWikiFromText(text)
+			requestedTime, err := wikipedia.ParseDate(strippedText, userNow(bot.Client(), request.Event().User))
+			if err != nil {
+				replyWithBlocks(response, text, getDateErrorAttachments(strippedText, err), true)
+				return
+			}
+
+			analyticsWiki, ok := wiki.(*wikipedia.MediaWiki)
+			if !ok || !analyticsWiki.Supports(wikipedia.FeaturePageviews) {
+				replyWithBlocks(response, text, getResultListHeader(fmt.Sprintf("Sorry, I don't have pageview information for %s.", wiki.Name())), true)
+				return
+			}
+			// Today's views are only known some hours after the day is over in UTC
+			requestedTime, attachments := getAvailableDay(wikipedia.PageviewsAvailability, requestedTime, len(strings.TrimSpace(strippedText)) != 0, "the trending articles")
+
+			pages, found := analyticsWiki.Trending(requestedTime, config.topDenylistFor(wiki), topResultsLimit)
+			attachments = append(attachments, getTrendingAttachments(pages, requestedTime, wiki, found)...)
+			replyWithBlocks(response, text, attachments, true)
+		},
+	}
+
+	defTopEdits := &slacker.CommandDefinition{
+		Description: "See the most edited articles for the given date or range of dates, with a summary of the edits. Provide no date to see the latest month's results.",
+		Example:     "topedits March 2020 lang=de",
+		Handler: func(request slacker.Request, response slacker.ResponseWriter) {
+			response.Typing()
+
+			text := request.StringParam("text", "")
+			wiki, strippedText := wikipedia.ParseWikiFromText(text)
+			requestedRange, isRange, err := wikipedia.ParseDateOrRange(strippedText, userNow(bot.Client(), request.Event().User))
+			if err != nil {
+				replyWithBlocks(response, text, getDateErrorAttachments(strippedText, err), true)
+				return
+			}
+
+			analyticsWiki, ok := wiki.(*wikipedia.MediaWiki)
+			if !ok || !analyticsWiki.Supports(wikipedia.FeatureEdits) {
+				replyWithBlocks(response, text, getResultListHeader(fmt.Sprintf("Sorry, I don't have edit information for %s.", wiki.Name())), true)
+				return
+			}
+
+			attachments := []slack.Block{}
+			if len(strings.TrimSpace(strippedText)) == 0 {
+				// Edit statistics come in a month at a time
+				requestedRange = wikipedia.EditsAvailability.LatestMonth()
+			} else if isRange {
+				availableRange, changed, found := wikipedia.EditsAvailability.AvailableRange(requestedRange, wikipedia.TopRangeMaxDays)
+				if !found {
+					replyWithBlocks(response, text, getTopEditsAttachments(wikipedia.EditActivity{}, requestedRange.String(), wiki, false), true)
+					return
+				}
+				if changed {
+					attachments = append(attachments, getChangedRangeAttachments(requestedRange, availableRange, "the most edited articles")...)
+				}
+				requestedRange = availableRange
+			} else {
+				day, switchDateAttachments := getAvailableDay(wikipedia.EditsAvailability, requestedRange.Start, true, "the most edited articles")
+				attachments = append(attachments, switchDateAttachments...)
+				requestedRange = wikipedia.NewDateRange(day, day)
+			}
 
+			activity, found := analyticsWiki.EditActivity(requestedRange, topResultsLimit)
+			attachments = append(attachments, getTopEditsAttachments(activity, requestedRange.String(), wiki, found)...)
+			replyWithBlocks(response, text, attachments, true)
 		},
 	}
 
@@ -184,11 +525,21 @@ func main() {
 	// bot.Command("related <text>", defRelated)
 	bot.Command("search <text>", defSearch)
 	bot.Command("top <text>", defTopviews)
+	bot.Command("trending <text>", defTrending)
+	bot.Command("topedits <text>", defTopEdits)
+	bot.Command("langs <text>", defLangs)
+	bot.Command("fact <text>", defFact)
+	bot.Command("nearby <text>", defNearby)
+	bot.Command("outline <text>", defOutline)
+	bot.Command("infobox <text>", defInfobox)
+	bot.Command("cite <text>", defCite)
+	bot.Command("views <text>", defViews)
+	bot.Command("compare <text>", defCompare)
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	err := bot.Listen(ctx)
+	err = bot.Listen(ctx)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -196,7 +547,7 @@ func main() {
 
 // Build the reply attachments for the commands, and answer properly
 // when a search text query was not found.
-func getFullReplyAttachments(searchText string, headerText string, results []wikipedia.Page, lang string) (att []slack.Block) {
+func getFullReplyAttachments(searchText string, headerText string, results []wikipedia.Page, wiki wikipedia.Backend) (att []slack.Block) {
 	attachments := []slack.Block{}
 	if len(strings.TrimSpace(searchText)) == 0 {
 		notFoundText := slack.NewTextBlockObject("mrkdwn",
@@ -208,7 +559,7 @@ func getFullReplyAttachments(searchText string, headerText string, results []wik
 	}
 	if len(results) == 0 || results[0].Title == "" || results[0].Title == "Not found." {
 		notFoundText := slack.NewTextBlockObject("mrkdwn",
-			fmt.Sprintf("I couldn't find anything related to \"*%s*\" on %s.Wikipedia :face_with_rolling_eyes: :grimacing:", searchText, lang),
+			fmt.Sprintf("I couldn't find anything related to \"*%s*\" on %s :face_with_rolling_eyes: :grimacing:", wikipedia.EscapeMrkdwn(searchText), wiki.Name()),
 			false, false)
 		notFoundSection := slack.NewSectionBlock(notFoundText, nil, nil)
 		attachments = append(attachments, notFoundSection)
@@ -224,6 +575,693 @@ func getFullReplyAttachments(searchText string, headerText string, results []wik
 	return attachments
 }
 
+// If the request doesn't ask for a wiki or a language, guess the language
+// from the query. Outputs the guessed language and a note for the reply
+// explaining the guess; or no language and no note. Nothing is guessed when
+// a wiki other than Wikipedia is the default, since the guess would send the
+// request to Wikipedia instead.
+func detectLanguage(text string, config Config) (lang string, note string) {
+	if config.DisableLanguageDetection || usesOtherDefaultWiki() || strings.Contains(text, "lang=") || strings.Contains(text, "wiki=") {
+		return "", ""
+	}
+	lang, reason, detected := wikipedia.DetectLanguage(wikipedia.StripParameters(text))
+	if !detected {
+		return "", ""
+	}
+	note = fmt.Sprintf(":mag: I looked this up on %s because %s. Add `lang=xx` to pick another wiki.", wikipedia.Wikipedia(lang).Name(), reason)
+	return lang, note
+}
+
+// Output the text of a request for the fetchers, which read the wiki from
+// it, with the guessed language added as lang=xx if there is one
+func withDetectedLanguage(text string, lang string) string {
+	if len(lang) == 0 {
+		return text
+	}
+	return text + " lang=" + lang
+}
+
+// Check whether requests that don't ask for a wiki go to a configured wiki
+// rather than Wikipedia
+func usesOtherDefaultWiki() bool {
+	wiki := wikipedia.DefaultWiki()
+	if wiki == nil {
+		return false
+	}
+	mediaWiki, ok := wiki.(*wikipedia.MediaWiki)
+	return !ok || !strings.HasSuffix(strings.TrimRight(mediaWiki.BaseURL, "/"), ".wikipedia.org")
+}
+
+// Look for the name=yes or name=no expression in the text, and output
+// whether it is enabled, falling back on the given default
+func parseToggleFromText(text string, name string, defaultValue bool) (enabled bool, remainingText string) {
+	r := regexp.MustCompile(name + "=(yes|no|on|off|true|false)")
+	match := r.FindStringSubmatch(text)
+	if len(match) == 0 {
+		return defaultValue, text
+	}
+	enabled = match[1] == "yes" || match[1] == "on" || match[1] == "true"
+	return enabled, strings.TrimSpace(r.ReplaceAllString(text, ""))
+}
+
+// Build the blocks of a Wikidata facts card, with the facts in two columns
+func getFactsCardAttachments(card wikipedia.FactsCard) (att []slack.Block) {
+	attachments := []slack.Block{}
+	if len(card.Facts) == 0 {
+		return attachments
+	}
+
+	context := fmt.Sprintf(":card_index: Facts from Wikidata %s", pageLink(card.URL, card.Item))
+	if len(card.Type) != 0 {
+		context += fmt.Sprintf(" (%s)", wikipedia.EscapeMrkdwn(card.Type))
+	}
+	attachments = append(attachments, slack.NewContextBlock("",
+		slack.NewTextBlockObject("mrkdwn", context, false, false)))
+
+	fields := []*slack.TextBlockObject{}
+	for _, fact := range card.Facts {
+		// Sections show up to 10 fields
+		if len(fields) >= 10 {
+			break
+		}
+		fields = append(fields, slack.NewTextBlockObject("mrkdwn", fmt.Sprintf("*%s*\n%s", wikipedia.EscapeMrkdwn(fact.Label), wikipedia.EscapeMrkdwn(fact.Value)), false, false))
+	}
+	return append(attachments, slack.NewSectionBlock(nil, fields, nil))
+}
+
+// Build the reply attachments for the answer of the "fact" command
+func getFactAnswerAttachments(question string, answer wikipedia.FactAnswer, wiki wikipedia.Backend, found bool) (att []slack.Block) {
+	replyText := ""
+	switch {
+	case len(answer.Property) == 0:
+		replyText = fmt.Sprintf("I'm not sure which fact you're asking for in \"*%s*\". Try something like `fact population of Tokyo` or `fact Ada Lovelace birth date`.", wikipedia.EscapeMrkdwn(question))
+	case len(answer.Item) == 0:
+		replyText = fmt.Sprintf("I couldn't find the article for \"*%s*\" on %s :face_with_rolling_eyes: :grimacing:", wikipedia.EscapeMrkdwn(question), wiki.Name())
+	case !found:
+		replyText = fmt.Sprintf("Wikidata doesn't have that fact about *%s* :face_with_rolling_eyes: (%s)", pageLink(answer.Page.URL, answer.Page.Title), pageLink(answer.URL, answer.Item))
+	}
+	if len(replyText) != 0 {
+		return []slack.Block{slack.NewSectionBlock(slack.NewTextBlockObject("mrkdwn", replyText, false, false), nil, nil)}
+	}
+
+	lines := []string{fmt.Sprintf("*%s* of %s:", wikipedia.EscapeMrkdwn(capitalizeFirst(answer.PropertyLabel)), pageLink(answer.Page.URL, answer.Page.Title))}
+	sources := []string{}
+	for _, value := range answer.Values {
+		line := fmt.Sprintf("• *%s*", wikipedia.EscapeMrkdwn(value.Value))
+		if len(value.Qualifiers) > 0 {
+			line += fmt.Sprintf(" (%s)", wikipedia.EscapeMrkdwn(strings.Join(value.Qualifiers, ", ")))
+		}
+		lines = append(lines, line)
+
+		source := value.SourceLabel
+		if len(value.SourceURL) != 0 {
+			if len(source) == 0 {
+				source = "source"
+			}
+			source = pageLink(value.SourceURL, source)
+		}
+		if len(source) != 0 {
+			sources = append(sources, source)
+		}
+	}
+
+	attachments := getTextSections(lines)
+	sources = append(wikipedia.UniqueStrings(sources), pageLink(answer.URL, "Wikidata "+answer.Item))
+	return append(attachments, getNoteAttachments("Sources: "+strings.Join(sources, " · "))...)
+}
+
+// Output the given text with its first letter in upper case
+func capitalizeFirst(text string) string {
+	for _, first := range text {
+		return string(unicode.ToUpper(first)) + text[utf8.RuneLen(first):]
+	}
+	return text
+}
+
+// Build the reply attachments for the infobox of an article, with its
+// main image and caption
+func getInfoboxAttachments(searchText string, infobox wikipedia.Infobox, wiki wikipedia.Backend, found bool) (att []slack.Block) {
+	if len(strings.TrimSpace(searchText)) == 0 || infobox.Page.Title == "Not found." {
+		return getFullReplyAttachments(searchText, "", []wikipedia.Page{infobox.Page}, wiki)
+	}
+	if !found {
+		return getResultListHeader(fmt.Sprintf("%s on %s doesn't have an infobox.", pageLink(infobox.Page.URL, infobox.Page.Title), wiki.Name()))
+	}
+
+	attachments := getResultListHeader(fmt.Sprintf("Infobox of %s on %s:", pageLink(infobox.Page.URL, infobox.Page.Title), wiki.Name()))
+	if len(infobox.Image) != 0 {
+		var caption *slack.TextBlockObject
+		if len(infobox.Caption) != 0 {
+			caption = slack.NewTextBlockObject("plain_text", truncateText(infobox.Caption, listExtractLimit), false, false)
+		}
+		attachments = append(attachments, slack.NewImageBlock(infobox.Image, infobox.Page.Title, "", caption))
+	}
+	return append(attachments, getInfoboxFieldsAttachments(infobox)...)
+}
+
+// Build two-column sections with the fields of an infobox
+func getInfoboxFieldsAttachments(infobox wikipedia.Infobox) (att []slack.Block) {
+	attachments := []slack.Block{}
+	fields := []*slack.TextBlockObject{}
+	for _, field := range infobox.Fields {
+		value := truncateText(wikipedia.EscapeMrkdwn(field.Value), sideBySideExtractLimit)
+		text := truncateText(fmt.Sprintf("*%s*\n%s", wikipedia.EscapeMrkdwn(field.Label), value), fieldTextLimit)
+		fields = append(fields, slack.NewTextBlockObject("mrkdwn", text, false, false))
+
+		// Sections show up to 10 fields
+		if len(fields) == 10 {
+			attachments = append(attachments, slack.NewSectionBlock(nil, fields, nil))
+			fields = []*slack.TextBlockObject{}
+		}
+	}
+	if len(fields) != 0 {
+		attachments = append(attachments, slack.NewSectionBlock(nil, fields, nil))
+	}
+	return attachments
+}
+
+// Output a note with the details of the current revision of an article,
+// with the time of the last edit in the given timezone
+func getMetadataNote(metadata wikipedia.PageMetadata, location *time.Location) string {
+	details := []string{}
+	if !metadata.LastEdited.IsZero() {
+		details = append(details, "Last edited "+metadata.LastEdited.In(location).Format("2 Jan 2006, 15:04 MST"))
+	}
+	if metadata.Length != 0 {
+		details = append(details, formatCount(metadata.Length)+" bytes")
+	}
+	if len(metadata.Protection) != 0 {
+		details = append(details, ":lock: "+wikipedia.EscapeMrkdwn(capitalizeFirst(metadata.Protection)))
+	}
+	if len(metadata.AssessmentClass) != 0 {
+		details = append(details, "Class: "+wikipedia.EscapeMrkdwn(metadata.AssessmentClass))
+	}
+	if len(metadata.PermanentURL) != 0 {
+		details = append(details, fmt.Sprintf("<%s|Permanent link (revision %d)>", metadata.PermanentURL, metadata.RevisionID))
+	}
+	if len(details) == 0 {
+		return ""
+	}
+	return ":pencil2: " + strings.Join(details, " · ")
+}
+
+// Format a number with thousands separators, like "181,234"
+func formatCount(number int) string {
+	digits := fmt.Sprintf("%d", number)
+	for i := len(digits) - 3; i > 0 && digits[i-1] != '-'; i -= 3 {
+		digits = digits[:i] + "," + digits[i:]
+	}
+	return digits
+}
+
+// Build the reply attachments for a citation, in a code block that is
+// easy to copy
+func getCitationAttachments(searchText string, citation wikipedia.Citation, style string, wiki wikipedia.Backend, found bool) (att []slack.Block) {
+	if !found {
+		return getFullReplyAttachments(searchText, "", nil, wiki)
+	}
+
+	attachments := getResultListHeader(fmt.Sprintf("Here's a %s citation of %s, revision %d:", strings.ToUpper(style), pageLink(citation.URL, citation.Title), citation.RevisionID))
+	attachments = append(attachments, slack.NewSectionBlock(
+		slack.NewTextBlockObject("mrkdwn", "```"+wikipedia.EscapeMrkdwn(citation.Format(style))+"```", false, false),
+		nil,
+		nil))
+	return attachments
+}
+
+// Build the reply attachments for the pageviews of an article, with the
+// totals as fields. The chart is uploaded separately.
+func getPageviewsAttachments(searchText string, views wikipedia.ArticleViews, wiki wikipedia.Backend, found bool) (att []slack.Block) {
+	if analyticsWiki, ok := wiki.(*wikipedia.MediaWiki); !ok || !analyticsWiki.Supports(wikipedia.FeaturePageviews) {
+		return getResultListHeader(fmt.Sprintf("Sorry, I don't have pageview information for %s.", wiki.Name()))
+	}
+	if !found {
+		return getFullReplyAttachments(searchText, "", nil, wiki)
+	}
+
+	query := views.Query
+	filters := []string{query.Granularity}
+	if query.Access != wikipedia.PageviewsAccess[0] {
+		filters = append(filters, query.Access)
+	}
+	if query.Agent != wikipedia.PageviewsAgents[0] {
+		filters = append(filters, query.Agent)
+	}
+	attachments := getResultListHeader(fmt.Sprintf("Here are the pageviews of %s for *%s* (%s):",
+		pageLink(views.Page.URL, views.Page.Title), query.Range, strings.Join(filters, ", ")))
+
+	stats := views.Stats()
+	period, peakFormat := "day", "Jan 02 2006"
+	if query.Granularity == "monthly" {
+		period, peakFormat = "month", "January 2006"
+	}
+	change := "No views before"
+	if stats.HasChange {
+		change = fmt.Sprintf("%+.1f%% (from %s)", stats.Change, formatCount(stats.PreviousTotal))
+	}
+	fields := []*slack.TextBlockObject{
+		slack.NewTextBlockObject("mrkdwn", "*Total*\n"+formatCount(stats.Total), false, false),
+		slack.NewTextBlockObject("mrkdwn", fmt.Sprintf("*Average per %s*\n%s", period, formatCount(stats.Average)), false, false),
+		slack.NewTextBlockObject("mrkdwn", fmt.Sprintf("*Peak %s*\n%s on %s", period, formatCount(stats.Peak.Views), stats.Peak.Date.Format(peakFormat)), false, false),
+		slack.NewTextBlockObject("mrkdwn", "*Change vs previous period*\n"+change, false, false),
+	}
+	attachments = append(attachments, slack.NewSectionBlock(nil, fields, nil))
+	return attachments
+}
+
+// Build the chart of the pageviews of an article
+func getPageviewsChart(views wikipedia.ArticleViews) lineChart {
+	labelFormat := "Jan 02"
+	if views.Query.Granularity == "monthly" {
+		labelFormat = "Jan 2006"
+	}
+	// The chart font has no dashes other than the ASCII one
+	chart := lineChart{title: views.Page.Title + " (" + strings.ReplaceAll(views.Query.Range.String(), "–", "-") + ")"}
+	values := []float64{}
+	for _, point := range views.Points {
+		chart.labels = append(chart.labels, point.Date.Format(labelFormat))
+		values = append(values, float64(point.Views))
+	}
+	chart.series = []chartSeries{{views.Page.Title, values}}
+	return chart
+}
+
+// Build the reply attachments for a comparison of pageviews, with a table
+// of the articles from the most to the least viewed
+func getComparisonAttachments(searchText string, comparison wikipedia.PageviewsComparison, wiki wikipedia.Backend, found bool) (att []slack.Block) {
+	if analyticsWiki, ok := wiki.(*wikipedia.MediaWiki); !ok || !analyticsWiki.Supports(wikipedia.FeaturePageviews) {
+		return getResultListHeader(fmt.Sprintf("Sorry, I don't have pageview information for %s.", wiki.Name()))
+	}
+	if !found {
+		return getFullReplyAttachments(searchText, "", nil, wiki)
+	}
+
+	ranking := comparison.Ranking()
+	links := []string{}
+	for _, article := range ranking {
+		links = append(links, pageLink(article.Views.Page.URL, article.Views.Page.Title))
+	}
+	attachments := getResultListHeader(fmt.Sprintf("Here's how %s compare for *%s* (%s):",
+		strings.Join(links, ", "), comparison.Query.Range, comparison.Query.Granularity))
+
+	rows := [][]string{{"#", "Article", "Total", "Average", "Change"}}
+	for index, article := range ranking {
+		change := "-"
+		if article.Stats.HasChange {
+			change = fmt.Sprintf("%+.1f%%", article.Stats.Change)
+		}
+		rows = append(rows, []string{
+			fmt.Sprintf("%d", index+1),
+			truncateText(article.Views.Page.Title, 30),
+			formatCount(article.Stats.Total),
+			formatCount(article.Stats.Average),
+			change,
+		})
+	}
+	attachments = append(attachments, slack.NewSectionBlock(
+		slack.NewTextBlockObject("mrkdwn", "```"+wikipedia.EscapeMrkdwn(formatTable(rows))+"```", false, false),
+		nil,
+		nil))
+
+	notes := []string{}
+	for _, article := range comparison.Articles {
+		if !article.Found {
+			notes = append(notes, fmt.Sprintf("I couldn't find \"%s\".", wikipedia.EscapeMrkdwn(article.Requested[0])))
+		} else if len(article.Requested) > 1 {
+			notes = append(notes, fmt.Sprintf("%s all lead to *%s*, so they are counted once.",
+				wikipedia.EscapeMrkdwn("\""+strings.Join(article.Requested, "\", \"")+"\""), wikipedia.EscapeMrkdwn(article.Views.Page.Title)))
+		}
+	}
+	attachments = append(attachments, getNoteAttachments(strings.Join(notes, " "))...)
+	return attachments
+}
+
+// Build the chart of a comparison of pageviews, with a line per article
+func getComparisonChart(comparison wikipedia.PageviewsComparison) lineChart {
+	labelFormat := "Jan 02"
+	if comparison.Query.Granularity == "monthly" {
+		labelFormat = "Jan 2006"
+	}
+	// The chart font has no dashes other than the ASCII one
+	chart := lineChart{title: "Pageviews (" + strings.ReplaceAll(comparison.Query.Range.String(), "–", "-") + ")"}
+	for index, article := range comparison.Ranking() {
+		values := []float64{}
+		for _, point := range article.Views.Points {
+			if index == 0 {
+				chart.labels = append(chart.labels, point.Date.Format(labelFormat))
+			}
+			values = append(values, float64(point.Views))
+		}
+		chart.series = append(chart.series, chartSeries{article.Views.Page.Title, values})
+	}
+	return chart
+}
+
+// Format rows of text as a table with aligned columns, for a code block.
+// Columns after the second are aligned to the right, for numbers.
+func formatTable(rows [][]string) string {
+	widths := []int{}
+	for _, row := range rows {
+		for column, cell := range row {
+			if column == len(widths) {
+				widths = append(widths, 0)
+			}
+			if length := utf8.RuneCountInString(cell); length > widths[column] {
+				widths[column] = length
+			}
+		}
+	}
+	lines := []string{}
+	for _, row := range rows {
+		cells := []string{}
+		for column, cell := range row {
+			padding := strings.Repeat(" ", widths[column]-utf8.RuneCountInString(cell))
+			if column < 2 {
+				cells = append(cells, cell+padding)
+			} else {
+				cells = append(cells, padding+cell)
+			}
+		}
+		lines = append(lines, strings.TrimRight(strings.Join(cells, "  "), " "))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Output the day to show results for: the requested day, or the latest
+// day the availability has data for if the requested day doesn't have any
+// yet, since the analytics only have whole UTC days. If the user asked for
+// the date, the output attachments tell them about the change.
+func getAvailableDay(availability wikipedia.DateAvailability, requestedTime time.Time, askedForDate bool, what string) (day time.Time, att []slack.Block) {
+	// The analytics data of a day comes in some time after the day is
+	// over in UTC; fall back to the latest day that has it
+	newRequestedTime, changed := availability.AvailableDay(requestedTime)
+	// Let the user know, but only if the user actually requested a date and not an empty string
+	if !changed || !askedForDate {
+		return newRequestedTime, []slack.Block{}
+	}
+	humanReadableOrig := fmt.Sprintf("%s %02d %d", requestedTime.Month(), requestedTime.Day(), requestedTime.Year())
+	humanReadableNew := fmt.Sprintf("%s %02d %d", newRequestedTime.Month(), newRequestedTime.Day(), newRequestedTime.Year())
+
+	switchDateText := slack.NewTextBlockObject("mrkdwn",
+		fmt.Sprintf("I don't have information yet for %s on *%s*. Let's see if I can find any results for *%s* instead.", what, humanReadableOrig, humanReadableNew),
+		false, false)
+	return newRequestedTime, []slack.Block{slack.NewSectionBlock(switchDateText, nil, nil)}
+}
+
+// Build the reply attachments for a date that couldn't be read
+func getDateErrorAttachments(datestring string, err error) (att []slack.Block) {
+	fmt.Printf("Could not parse the date \"%s\": %v\n", datestring, err)
+	return getResultListHeader(fmt.Sprintf("Sorry, I couldn't understand the date *\"%s\"*. Try something like \"March 1 2020\", \"yesterday\" or \"last week\". :calendar:", wikipedia.EscapeMrkdwn(datestring)))
+}
+
+// Build the reply attachments that tell the user the range of days was
+// narrowed down to the days that have data, or to at most a year
+func getChangedRangeAttachments(requestedRange wikipedia.DateRange, availableRange wikipedia.DateRange, what string) (att []slack.Block) {
+	reasons := []string{}
+	if !availableRange.End.Equal(requestedRange.End) {
+		reasons = append(reasons, fmt.Sprintf("I don't have information yet for %s after *%s*", what, availableRange.End.Format("Jan 02 2006")))
+	}
+	// The start only moves when the days up to the new end are over the cap
+	if wikipedia.NewDateRange(requestedRange.Start, availableRange.End).Days() > wikipedia.TopRangeMaxDays {
+		reasons = append(reasons, "I can only add up a year at a time")
+	}
+	switchRangeText := slack.NewTextBlockObject("mrkdwn",
+		fmt.Sprintf("%s, so let's see the results for *%s* instead.", strings.Join(reasons, ", and "), availableRange),
+		false, false)
+	return []slack.Block{slack.NewSectionBlock(switchRangeText, nil, nil)}
+}
+
+// Output a note listing the days and months whose lists couldn't be fetched
+func getMissingDaysNote(missing []wikipedia.DateRange) string {
+	labels := []string{}
+	for _, segment := range missing {
+		if len(labels) == missingDaysListed {
+			labels = append(labels, fmt.Sprintf("and %d more", len(missing)-missingDaysListed))
+			break
+		}
+		labels = append(labels, segment.String())
+	}
+	return fmt.Sprintf(":warning: I couldn't get the views of %s, so they aren't counted in the totals.", strings.Join(labels, ", "))
+}
+
+// Build the reply attachments for the most edited articles of a range of
+// days, with a summary of the edits and the most active editors
+func getTopEditsAttachments(activity wikipedia.EditActivity, formattedRequestedTime string, wiki wikipedia.Backend, found bool) (att []slack.Block) {
+	if !found {
+		return getResultListHeader(fmt.Sprintf("Oops, I couldn't find the most edited articles in %s for *\"%s\"*. Edit statistics are only published once a month, so the last few weeks may be missing. :grimacing:", wiki.Name(), formattedRequestedTime))
+	}
+
+	attachments := getResultListHeader(fmt.Sprintf("Most edited articles for *%s* on %s", formattedRequestedTime, wiki.Name()))
+	for _, page := range activity.Pages {
+		attachments = append(attachments, slack.NewSectionBlock(slack.NewTextBlockObject(
+			"mrkdwn",
+			fmt.Sprintf("*%d most edited:* %s (%s edits)", page.Rank, pageLink(page.URL, page.Title), page.Info),
+			false, false),
+			nil, nil))
+	}
+
+	// Counts that couldn't be fetched are left out rather than shown as 0
+	summary := []string{}
+	if activity.HasEdits {
+		summary = append(summary, formatCount(activity.Edits)+" edits to articles")
+	}
+	if activity.HasEdits && activity.Edits != 0 {
+		if activity.HasAnonymousEdits {
+			summary = append(summary, fmt.Sprintf("%.0f%% by people who weren't logged in", float64(activity.AnonymousEdits)/float64(activity.Edits)*100))
+		}
+		if activity.HasBotEdits {
+			summary = append(summary, fmt.Sprintf("%.0f%% by bots", float64(activity.BotEdits)/float64(activity.Edits)*100))
+		}
+	}
+	editors := []string{}
+	for _, editor := range activity.Editors {
+		editors = append(editors, fmt.Sprintf("%s (%s)", pageLink(editor.URL, editor.Title), editor.Info))
+	}
+	if len(editors) != 0 {
+		summary = append(summary, "Most active editors: "+strings.Join(editors, ", "))
+	}
+	if len(summary) != 0 {
+		attachments = append(attachments, getNoteAttachments(":pencil2: "+strings.Join(summary, " · "))...)
+	}
+	return attachments
+}
+
+// Build the reply attachments for the trending articles of a day, with
+// their description and how much their views rose
+func getTrendingAttachments(pages []wikipedia.TrendingPage, day time.Time, wiki wikipedia.Backend, found bool) (att []slack.Block) {
+	formattedDay := day.Format("January 02 2006")
+	if !found {
+		return getResultListHeader(fmt.Sprintf("I couldn't find any articles trending on %s on *%s*. :face_with_rolling_eyes:", wiki.Name(), formattedDay))
+	}
+
+	attachments := getResultListHeader(fmt.Sprintf("Trending on %s on *%s*, compared with the week before:", wiki.Name(), formattedDay))
+	for _, page := range pages {
+		line := fmt.Sprintf("*%d.* %s", page.Rank, pageLink(page.URL, page.Title))
+		if len(page.Description) != 0 {
+			line += " – " + wikipedia.EscapeMrkdwn(page.Description)
+		}
+		line += fmt.Sprintf("\n%s views, %.1f× the daily average of %s", formatCount(page.Views), page.Increase, formatCount(page.Baseline))
+		attachments = append(attachments, slack.NewSectionBlock(slack.NewTextBlockObject("mrkdwn", line, false, false), nil, nil))
+	}
+	return attachments
+}
+
+// Output a small context block with the given note, or nothing if there is no note
+func getNoteAttachments(note string) (att []slack.Block) {
+	if len(note) == 0 {
+		return []slack.Block{}
+	}
+	return []slack.Block{slack.NewContextBlock("", slack.NewTextBlockObject("mrkdwn", note, false, false))}
+}
+
+// Build the reply attachments for a term that was looked up in several
+// wikis at once, with the summaries side by side. Wikis that timed out
+// or had no results are listed underneath.
+func getMultipleWikisReplyAttachments(searchText string, results []wikipedia.WikiResult) (att []slack.Block) {
+	if len(strings.TrimSpace(searchText)) == 0 {
+		return getFullReplyAttachments(searchText, "", nil, nil)
+	}
+
+	fields := []*slack.TextBlockObject{}
+	missing := []string{}
+	for _, result := range results {
+		if result.TimedOut {
+			missing = append(missing, fmt.Sprintf("%s didn't answer in time", result.Wiki.Name()))
+			continue
+		}
+		if len(result.Results) == 0 || result.Results[0].Title == "Not found." {
+			missing = append(missing, fmt.Sprintf("nothing found on %s", result.Wiki.Name()))
+			continue
+		}
+		page := result.Results[0]
+		fields = append(fields, slack.NewTextBlockObject(
+			"mrkdwn",
+			fmt.Sprintf("*%s*\n*%s*\n%s", result.Wiki.Name(), pageLink(page.URL, page.Title), truncateText(pageExtract(page), sideBySideExtractLimit)),
+			false, false))
+	}
+
+	if len(fields) == 0 {
+		notFoundText := slack.NewTextBlockObject("mrkdwn",
+			fmt.Sprintf("I couldn't find anything related to \"*%s*\" in any of these languages :face_with_rolling_eyes: :grimacing: (%s)", wikipedia.EscapeMrkdwn(searchText), strings.Join(missing, ", ")),
+			false, false)
+		return []slack.Block{slack.NewSectionBlock(notFoundText, nil, nil)}
+	}
+
+	attachments := getResultListHeader(fmt.Sprintf("Here's what I found for \"*%s*\" in %d languages:", wikipedia.EscapeMrkdwn(searchText), len(fields)))
+	// Sections show up to 10 fields, in two columns
+	for start := 0; start < len(fields); start += 10 {
+		end := start + 10
+		if end > len(fields) {
+			end = len(fields)
+		}
+		attachments = append(attachments, slack.NewSectionBlock(nil, fields[start:end], nil))
+	}
+	if len(missing) > 0 {
+		attachments = append(attachments, slack.NewContextBlock("",
+			slack.NewTextBlockObject("mrkdwn", strings.Join(missing, ", "), false, false)))
+	}
+	return attachments
+}
+
+// Build the reply attachments for the list of languages an article is
+// available in. Team languages are listed first, highlighting the ones the
+// article is missing in; onlyTeam leaves out all the other languages.
+func getLanguageLinksAttachments(searchText string, page wikipedia.Page, wiki wikipedia.Backend, links []wikipedia.LanguageLink, teamLanguages []string, onlyTeam bool) (att []slack.Block) {
+	if len(strings.TrimSpace(searchText)) == 0 || page.Title == "Not found." {
+		return getFullReplyAttachments(searchText, "", []wikipedia.Page{page}, wiki)
+	}
+
+	byLang := map[string]wikipedia.LanguageLink{}
+	for _, link := range links {
+		byLang[link.Lang] = link
+	}
+	formatLink := func(link wikipedia.LanguageLink) string {
+		return fmt.Sprintf("`%s` %s: %s", link.Lang, wikipedia.EscapeMrkdwn(link.LanguageName), pageLink(link.URL, link.Title))
+	}
+
+	attachments := getResultListHeader(fmt.Sprintf("*%s* on %s is available in %d other languages:", pageLink(page.URL, page.Title), wiki.Name(), len(links)))
+
+	if len(teamLanguages) > 0 {
+		teamLines := []string{"*Team languages*"}
+		isTeam := map[string]bool{}
+		for _, lang := range teamLanguages {
+			isTeam[lang] = true
+			if lang == wiki.Language() {
+				continue
+			}
+			if link, ok := byLang[lang]; ok {
+				teamLines = append(teamLines, ":white_check_mark: "+formatLink(link))
+			} else {
+				teamLines = append(teamLines, fmt.Sprintf(":x: `%s` *missing*", lang))
+			}
+		}
+		attachments = append(attachments, getTextSections(teamLines)...)
+
+		if onlyTeam {
+			return attachments
+		}
+		others := []wikipedia.LanguageLink{}
+		for _, link := range links {
+			if !isTeam[link.Lang] {
+				others = append(others, link)
+			}
+		}
+		links = others
+	}
+
+	lines := []string{}
+	for _, link := range links {
+		lines = append(lines, formatLink(link))
+	}
+	return append(attachments, getTextSections(lines)...)
+}
+
+// Build the reply attachments for a single section of an article. If the
+// section wasn't found, list the sections the article does have.
+func getSectionAttachments(result wikipedia.SectionResult, wiki wikipedia.Backend, actualTitle string, sectionName string) (att []slack.Block) {
+	if result.Page.Title == "Not found." {
+		return getFullReplyAttachments(actualTitle, "", []wikipedia.Page{result.Page}, wiki)
+	}
+	if result.Section == nil {
+		attachments := getResultListHeader(fmt.Sprintf("I couldn't find a section called \"*%s*\" in %s on %s.", wikipedia.EscapeMrkdwn(sectionName), pageLink(result.Page.URL, result.Page.Title), wiki.Name()))
+		lines := []string{}
+		for _, section := range result.Sections {
+			lines = append(lines, strings.Repeat("    ", section.Level-2)+section.Number+" "+wikipedia.EscapeMrkdwn(section.Line))
+		}
+		if len(lines) == 0 {
+			return attachments
+		}
+		return append(attachments, getTextSections(append([]string{"*Sections*"}, lines...))...)
+	}
+
+	headerText := fmt.Sprintf("Here's the \"*%s*\" section of \"*%s*\" on %s:", wikipedia.EscapeMrkdwn(result.Section.Line), wikipedia.EscapeMrkdwn(actualTitle), wiki.Name())
+	return getFullReplyAttachments(actualTitle, headerText, []wikipedia.Page{result.Page}, wiki)
+}
+
+// Build the reply attachments for the outline of an article, with a
+// "show" button for each section when withButtons is set
+func getOutlineAttachments(searchText string, page wikipedia.Page, sections []wikipedia.Section, wiki wikipedia.Backend, withButtons bool) (att []slack.Block) {
+	if len(strings.TrimSpace(searchText)) == 0 || page.Title == "Not found." {
+		return getFullReplyAttachments(searchText, "", []wikipedia.Page{page}, wiki)
+	}
+	if len(sections) == 0 {
+		return getResultListHeader(fmt.Sprintf("%s on %s has no sections.", pageLink(page.URL, page.Title), wiki.Name()))
+	}
+
+	attachments := getResultListHeader(fmt.Sprintf("Outline of %s on %s:", pageLink(page.URL, page.Title), wiki.Name()))
+	mediaWiki, _ := wiki.(*wikipedia.MediaWiki)
+	lines := []string{}
+	for _, section := range sections {
+		line := strings.Repeat("    ", section.Level-2) + section.Number + " " + pageLink(mediaWiki.SectionURL(page.Title, section), section.Line)
+		if !withButtons {
+			lines = append(lines, line)
+			continue
+		}
+		button := slack.NewButtonBlockElement(showSectionActionID, getSectionButtonValue(page.Title, wiki, section), slack.NewTextBlockObject("plain_text", "Show", false, false))
+		attachments = append(attachments, slack.NewSectionBlock(
+			slack.NewTextBlockObject("mrkdwn", line, false, false),
+			nil,
+			slack.NewAccessory(button)))
+	}
+	return append(attachments, getTextSections(lines)...)
+}
+
+// Build the reply attachments for the articles near a place, closest first
+func getNearbyAttachments(results []wikipedia.NearbyPage, center *wikipedia.Coordinates, place wikipedia.Page, radius int, wiki wikipedia.Backend) (att []slack.Block) {
+	if center == nil {
+		return getResultListHeader("Sorry, I couldn't find where that is. Try a place with an article, or coordinates like `48.8584,2.2945`.")
+	}
+
+	location := fmt.Sprintf("<%s|%s>", center.OpenStreetMapURL(), center.String())
+	if len(place.Title) != 0 {
+		location = pageLink(place.URL, place.Title)
+	}
+	if len(results) == 0 {
+		return getResultListHeader(fmt.Sprintf("I couldn't find any articles on %s within %s of %s.", wiki.Name(), formatDistance(float64(radius)), location))
+	}
+
+	attachments := getResultListHeader(fmt.Sprintf("Articles on %s within %s of %s:", wiki.Name(), formatDistance(float64(radius)), location))
+	lines := []string{}
+	for _, result := range results {
+		lines = append(lines, fmt.Sprintf("%s · %s", pageLink(result.URL, result.Title), formatDistance(result.Distance)))
+	}
+	attachments = append(attachments, getTextSections(lines)...)
+	return append(attachments, getNoteAttachments(getLocationNote(*center))...)
+}
+
+// Output the coordinates with a link to them on OpenStreetMap
+func getLocationNote(coordinates wikipedia.Coordinates) string {
+	return fmt.Sprintf(":round_pushpin: %s · <%s|OpenStreetMap>", coordinates.String(), coordinates.OpenStreetMapURL())
+}
+
+// Format a distance in meters as "350 m" or "1.2 km"
+func formatDistance(meters float64) string {
+	if meters < 1000 {
+		return fmt.Sprintf("%.0f m", meters)
+	}
+	return fmt.Sprintf("%.1f km", meters/1000)
+}
+
 // Add a header for the search results with a given text
 // attatchment parameter is the existing array of blocks from the result list
 func getResultListHeader(headerStringText string) (att []slack.Block) {
@@ -242,16 +1280,6 @@ func getResultListHeader(headerStringText string) (att []slack.Block) {
 
 // Build a slack block list from the results from the API
 func buildResultListAttachments(results []wikipedia.Page) (att []slack.Block) {
-
-	infoTextPrintf := ""
-	if len(results) > 1 {
-		// For multiple results, limit the extract
-		infoTextPrintf = "*<%s|%s>*\n%.150s[...]"
-	} else {
-		// For one result, don't limit the extract
-		infoTextPrintf = "*<%s|%s>*\n%s"
-	}
-
 	// Create the formatted response
 	attachments := []slack.Block{}
 
@@ -261,24 +1289,51 @@ func buildResultListAttachments(results []wikipedia.Page) (att []slack.Block) {
 			break
 		}
 
-		itemInfo := slack.NewTextBlockObject(
-			"mrkdwn",
-			fmt.Sprintf(infoTextPrintf, page.URL, page.Title, page.Extract),
-			false, false)
-
-		if page.Image != "" {
-			attachments = append(attachments, slack.NewSectionBlock(
-				itemInfo,
-				nil,
-				slack.NewAccessory(slack.NewImageBlockElement(page.Image, page.Title))))
+		title := fmt.Sprintf("*%s*", pageLink(page.URL, page.Title))
+		chunks := []string{}
+		if len(results) > 1 {
+			// For multiple results, limit the extract
+			chunks = append(chunks, title+"\n"+truncateText(pageExtract(page), listExtractLimit))
 		} else {
-			attachments = append(attachments, slack.NewSectionBlock(
-				// Item info
-				itemInfo,
-				nil,
-				nil))
+			// For one result, show all of the extract, in as many sections as it needs
+			chunks = splitText(title+"\n"+pageExtract(page), sectionTextLimit)
+		}
+
+		for chunkIndex, chunk := range chunks {
+			itemInfo := slack.NewTextBlockObject("mrkdwn", chunk, false, false)
+			if page.Image != "" && chunkIndex == 0 {
+				attachments = append(attachments, slack.NewSectionBlock(
+					itemInfo,
+					nil,
+					slack.NewAccessory(slack.NewImageBlockElement(page.Image, page.Title))))
+			} else {
+				attachments = append(attachments, slack.NewSectionBlock(
+					// Item info
+					itemInfo,
+					nil,
+					nil))
+			}
 		}
 	}
 
 	return attachments
 }
+
+// Output a Slack link to the given page, or only its title if
+// it has no url, like pages from offline archives
+func pageLink(url string, title string) string {
+	title = wikipedia.EscapeMrkdwn(title)
+	if len(url) == 0 {
+		return title
+	}
+	return fmt.Sprintf("<%s|%s>", url, title)
+}
+
+// Output the extract of a page as mrkdwn, keeping its formatting
+// when the wiki gave it
+func pageExtract(page wikipedia.Page) string {
+	if len(page.ExtractHTML) != 0 {
+		return wikipedia.HTMLToMrkdwn(page.ExtractHTML)
+	}
+	return wikipedia.EscapeMrkdwn(page.Extract)
+}
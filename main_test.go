@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mooeypoo/slack-wikipedia/wikipedia"
+)
+
+func Test_getTopEditsAttachments(t *testing.T) {
+	pages := []wikipedia.PagelistPage{{Title: "Paris", URL: "https://en.wikipedia.org/wiki/Paris", Rank: 1, Info: "120"}}
+	tests := []struct {
+		name       string
+		activity   wikipedia.EditActivity
+		expected   []string
+		unexpected []string
+	}{
+		{"All counts", wikipedia.EditActivity{Pages: pages, Edits: 200, AnonymousEdits: 50, BotEdits: 20, HasEdits: true, HasAnonymousEdits: true, HasBotEdits: true},
+			[]string{"200 edits to articles", "25% by people who weren't logged in", "10% by bots"}, nil},
+		{"Bot count failed", wikipedia.EditActivity{Pages: pages, Edits: 200, AnonymousEdits: 50, HasEdits: true, HasAnonymousEdits: true},
+			[]string{"200 edits to articles", "25% by people who weren't logged in"}, []string{"by bots"}},
+		{"Total failed", wikipedia.EditActivity{Pages: pages, AnonymousEdits: 50, BotEdits: 20, HasAnonymousEdits: true, HasBotEdits: true},
+			nil, []string{"edits to articles", "%"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			blocks, _ := json.Marshal(getTopEditsAttachments(tt.activity, "March 2020", wikipedia.Wikipedia("en"), true))
+			for _, text := range tt.expected {
+				if !strings.Contains(string(blocks), text) {
+					t.Errorf("getTopEditsAttachments() = %s, missing %q", blocks, text)
+				}
+			}
+			for _, text := range tt.unexpected {
+				if strings.Contains(string(blocks), text) {
+					t.Errorf("getTopEditsAttachments() = %s, shouldn't have %q", blocks, text)
+				}
+			}
+		})
+	}
+}
+
+func Test_getChangedRangeAttachments(t *testing.T) {
+	day := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+	tests := []struct {
+		name       string
+		requested  wikipedia.DateRange
+		available  wikipedia.DateRange
+		expected   []string
+		unexpected []string
+	}{
+		{"Cut at the end", wikipedia.NewDateRange(day(2020, 5, 25), day(2020, 6, 7)), wikipedia.NewDateRange(day(2020, 5, 25), day(2020, 6, 1)),
+			[]string{"after *Jun 01 2020*"}, []string{"a year at a time"}},
+		{"Cut to a year", wikipedia.NewDateRange(day(2018, 1, 1), day(2020, 5, 31)), wikipedia.NewDateRange(day(2019, 6, 1), day(2020, 5, 31)),
+			[]string{"a year at a time"}, []string{"after"}},
+		{"Centuries cut to a year", wikipedia.NewDateRange(day(1526, 10, 18), day(2026, 10, 17)), wikipedia.NewDateRange(day(2025, 10, 17), day(2026, 10, 17)),
+			[]string{"a year at a time"}, []string{"after"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			blocks, _ := json.Marshal(getChangedRangeAttachments(tt.requested, tt.available, "the top views"))
+			for _, text := range tt.expected {
+				if !strings.Contains(string(blocks), text) {
+					t.Errorf("getChangedRangeAttachments() = %s, missing %q", blocks, text)
+				}
+			}
+			for _, text := range tt.unexpected {
+				if strings.Contains(string(blocks), text) {
+					t.Errorf("getChangedRangeAttachments() = %s, shouldn't have %q", blocks, text)
+				}
+			}
+		})
+	}
+}
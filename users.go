@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/mooeypoo/slack-wikipedia/wikipedia"
+	"github.com/slack-go/slack"
+)
+
+// How long the timezone of a Slack user is remembered
+const userTimezoneTTL = 12 * time.Hour
+
+// userTimezone is a cached timezone of a Slack user
+type userTimezone struct {
+	location *time.Location
+	fetched  time.Time
+}
+
+var userTimezones = map[string]userTimezone{}
+var userTimezonesMutex sync.Mutex
+
+// Output the timezone of a Slack user, from their profile. Looking it up
+// needs the users:read scope. UTC is used if the user can't be looked up,
+// and remembered like a timezone that was found, so a missing scope isn't
+// asked about and logged on every request.
+func userLocation(client *slack.Client, userID string) *time.Location {
+	userTimezonesMutex.Lock()
+	cached, ok := userTimezones[userID]
+	userTimezonesMutex.Unlock()
+	if ok && time.Since(cached.fetched) < userTimezoneTTL {
+		return cached.location
+	}
+
+	location := time.UTC
+	if user, err := client.GetUserInfo(userID); err != nil {
+		fmt.Printf("Could not look up the timezone of %s, using UTC: %v\n", userID, err)
+	} else if loaded, err := time.LoadLocation(user.TZ); err == nil && len(user.TZ) != 0 {
+		location = loaded
+	} else if len(user.TZLabel) != 0 {
+		location = time.FixedZone(user.TZLabel, user.TZOffset)
+	}
+
+	userTimezonesMutex.Lock()
+	userTimezones[userID] = userTimezone{location, time.Now()}
+	userTimezonesMutex.Unlock()
+	return location
+}
+
+// Output the current time in the timezone of a Slack user, so dates like
+// "yesterday" mean the user's yesterday
+func userNow(client *slack.Client, userID string) time.Time {
+	return wikipedia.SystemClock.Now().In(userLocation(client, userID))
+}
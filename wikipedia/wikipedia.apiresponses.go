@@ -1,6 +1,7 @@
 package wikipedia
 
 import (
+	"encoding/json"
 	"time"
 )
 
@@ -43,6 +44,33 @@ type ActionAPIBaseResponsePageInfo struct {
 	Fullurl              string    `json:"fullurl"`
 	Editurl              string    `json:"editurl"`
 	Canonicalurl         string    `json:"canonicalurl"`
+	Pageprops            struct {
+		WikibaseItem string `json:"wikibase_item"`
+	} `json:"pageprops"`
+	Description string                 `json:"description"`
+	Coordinates []ActionAPICoordinates `json:"coordinates"`
+	Protection  []struct {
+		Type   string `json:"type"`
+		Level  string `json:"level"`
+		Expiry string `json:"expiry"`
+	} `json:"protection"`
+	Revisions []struct {
+		Revid     int       `json:"revid"`
+		Timestamp time.Time `json:"timestamp"`
+	} `json:"revisions"`
+	Pageassessments map[string]struct {
+		Class      string `json:"class"`
+		Importance string `json:"importance"`
+	} `json:"pageassessments"`
+}
+
+// ActionAPICoordinates is a location of a page, from prop=coordinates.
+// Primary is set, as an empty string, on the main location of the subject.
+type ActionAPICoordinates struct {
+	Lat     float64 `json:"lat"`
+	Lon     float64 `json:"lon"`
+	Primary *string `json:"primary"`
+	Globe   string  `json:"globe"`
 }
 
 // MultiplePageResponseREST is the wrapper around the response
@@ -88,7 +116,7 @@ type PageResponseREST struct {
 	Timestamp         string `json:"timestamp"`
 	Description       string `json:"description"`
 	DescriptionSource string `json:"description_source"`
-	Coordinates       struct {
+	Coordinates       *struct {
 		Lat float64 `json:"lat"`
 		Lon float64 `json:"lon"`
 	} `json:"coordinates"`
@@ -136,3 +164,226 @@ type AnalyticsPageviews struct {
 		} `json:"articles"`
 	} `json:"items"`
 }
+
+// AnalyticsArticlePageviews is the structure that is expected from the
+// Wikipedia analytics API when requesting the pageviews of a single
+// article over time (pageviews/per-article)
+type AnalyticsArticlePageviews struct {
+	Title  string `json:"title"`
+	Detail string `json:"detail"`
+	Items  []struct {
+		Project     string `json:"project"`
+		Article     string `json:"article"`
+		Granularity string `json:"granularity"`
+		Timestamp   string `json:"timestamp"`
+		Access      string `json:"access"`
+		Agent       string `json:"agent"`
+		Views       int    `json:"views"`
+	} `json:"items"`
+}
+
+// AnalyticsTopPerCountry is the structure that is expected from the
+// Wikipedia analytics API when requesting the top articles of all wikis
+// in a single country (pageviews/top-per-country). The views are rounded
+// up to protect the privacy of readers.
+type AnalyticsTopPerCountry struct {
+	Title  string `json:"title"`
+	Detail string `json:"detail"`
+	Items  []struct {
+		Country  string `json:"country"`
+		Access   string `json:"access"`
+		Year     string `json:"year"`
+		Month    string `json:"month"`
+		Day      string `json:"day"`
+		Articles []struct {
+			Article   string `json:"article"`
+			Project   string `json:"project"`
+			ViewsCeil int    `json:"views_ceil"`
+			Rank      int    `json:"rank"`
+		} `json:"articles"`
+	} `json:"items"`
+}
+
+// ActionAPILanglinksResponse is the structure expected from the
+// Wikipedia action API when requesting the interlanguage links
+// (prop=langlinks) of a page
+type ActionAPILanglinksResponse struct {
+	Query struct {
+		Pages map[string]struct {
+			Pageid    int    `json:"pageid"`
+			Title     string `json:"title"`
+			Langlinks []struct {
+				Lang     string `json:"lang"`
+				URL      string `json:"url"`
+				Langname string `json:"langname"`
+				Autonym  string `json:"autonym"`
+				Title    string `json:"*"`
+			} `json:"langlinks"`
+		} `json:"pages"`
+	} `json:"query"`
+}
+
+// WikidataEntitiesResponse is the structure expected from the
+// Wikidata action API for wbgetentities requests
+type WikidataEntitiesResponse struct {
+	Entities map[string]WikidataEntity `json:"entities"`
+	Error    struct {
+		Code string `json:"code"`
+		Info string `json:"info"`
+	} `json:"error"`
+}
+
+// WikidataEntity is a single item or property in the WikidataEntitiesResponse
+type WikidataEntity struct {
+	ID           string                     `json:"id"`
+	Missing      *string                    `json:"missing"`
+	Labels       map[string]WikidataText    `json:"labels"`
+	Descriptions map[string]WikidataText    `json:"descriptions"`
+	Claims       map[string][]WikidataClaim `json:"claims"`
+}
+
+// WikidataText is a text in a single language, like a label or a description
+type WikidataText struct {
+	Language string `json:"language"`
+	Value    string `json:"value"`
+}
+
+// WikidataClaim is a single statement about a property of an entity
+type WikidataClaim struct {
+	Mainsnak        WikidataSnak              `json:"mainsnak"`
+	Rank            string                    `json:"rank"`
+	Qualifiers      map[string][]WikidataSnak `json:"qualifiers"`
+	QualifiersOrder []string                  `json:"qualifiers-order"`
+	References      []struct {
+		Snaks map[string][]WikidataSnak `json:"snaks"`
+	} `json:"references"`
+}
+
+// WikidataSnak is a property and value pair. The structure of the
+// value depends on its type, so it is decoded separately.
+type WikidataSnak struct {
+	Snaktype  string `json:"snaktype"`
+	Property  string `json:"property"`
+	Datatype  string `json:"datatype"`
+	Datavalue struct {
+		Type  string          `json:"type"`
+		Value json.RawMessage `json:"value"`
+	} `json:"datavalue"`
+}
+
+// ActionAPIGeosearchResponse is the structure expected from the
+// Wikipedia action API for list=geosearch requests
+type ActionAPIGeosearchResponse struct {
+	Query struct {
+		Geosearch []struct {
+			Pageid int     `json:"pageid"`
+			Ns     int     `json:"ns"`
+			Title  string  `json:"title"`
+			Lat    float64 `json:"lat"`
+			Lon    float64 `json:"lon"`
+			Dist   float64 `json:"dist"`
+		} `json:"geosearch"`
+	} `json:"query"`
+	Error struct {
+		Code string `json:"code"`
+		Info string `json:"info"`
+	} `json:"error"`
+}
+
+// ActionAPIParseSectionsResponse is the structure expected from the
+// Wikipedia action API for action=parse&prop=sections requests
+type ActionAPIParseSectionsResponse struct {
+	Parse struct {
+		Title    string `json:"title"`
+		Pageid   int    `json:"pageid"`
+		Sections []struct {
+			Toclevel int    `json:"toclevel"`
+			Level    string `json:"level"`
+			Line     string `json:"line"`
+			Number   string `json:"number"`
+			Index    string `json:"index"`
+			Anchor   string `json:"anchor"`
+		} `json:"sections"`
+	} `json:"parse"`
+	Error struct {
+		Code string `json:"code"`
+		Info string `json:"info"`
+	} `json:"error"`
+}
+
+// ActionAPIParseTextResponse is the structure expected from the Wikipedia
+// action API for action=parse&prop=text requests, with formatversion=2
+type ActionAPIParseTextResponse struct {
+	Parse struct {
+		Title  string `json:"title"`
+		Pageid int    `json:"pageid"`
+		Text   string `json:"text"`
+	} `json:"parse"`
+}
+
+// ActionAPISiteInfoResponse is the structure expected from the
+// Wikipedia action API when requesting the general information and
+// namespaces of the wiki (meta=siteinfo), with formatversion=2
+type ActionAPISiteInfoResponse struct {
+	Query struct {
+		General struct {
+			Mainpage string `json:"mainpage"`
+			Lang     string `json:"lang"`
+		} `json:"general"`
+		Namespaces map[string]struct {
+			ID        int    `json:"id"`
+			Name      string `json:"name"`
+			Canonical string `json:"canonical"`
+		} `json:"namespaces"`
+		Namespacealiases []struct {
+			ID    int    `json:"id"`
+			Alias string `json:"alias"`
+		} `json:"namespacealiases"`
+	} `json:"query"`
+	Error struct {
+		Code string `json:"code"`
+		Info string `json:"info"`
+	} `json:"error"`
+}
+
+// AnalyticsTopByEdits is the structure that is expected from the
+// Wikipedia analytics API when requesting the most edited pages
+// (edited-pages/top-by-edits) or the most active editors
+// (editors/top-by-edits) of a day or a month
+type AnalyticsTopByEdits struct {
+	Title  string `json:"title"`
+	Detail string `json:"detail"`
+	Items  []struct {
+		Project     string `json:"project"`
+		EditorType  string `json:"editor-type"`
+		PageType    string `json:"page-type"`
+		Granularity string `json:"granularity"`
+		Results     []struct {
+			Timestamp string `json:"timestamp"`
+			Top       []struct {
+				PageTitle string `json:"page_title"`
+				UserText  string `json:"user_text"`
+				Edits     int    `json:"edits"`
+				Rank      int    `json:"rank"`
+			} `json:"top"`
+		} `json:"results"`
+	} `json:"items"`
+}
+
+// AnalyticsEditsAggregate is the structure that is expected from the
+// Wikipedia analytics API when requesting the number of edits over
+// time (edits/aggregate)
+type AnalyticsEditsAggregate struct {
+	Title  string `json:"title"`
+	Detail string `json:"detail"`
+	Items  []struct {
+		Project     string `json:"project"`
+		EditorType  string `json:"editor-type"`
+		PageType    string `json:"page-type"`
+		Granularity string `json:"granularity"`
+		Results     []struct {
+			Timestamp string `json:"timestamp"`
+			Edits     int    `json:"edits"`
+		} `json:"results"`
+	} `json:"items"`
+}
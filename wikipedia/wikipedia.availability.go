@@ -0,0 +1,120 @@
+package wikipedia
+
+import (
+	"time"
+)
+
+// PageviewsLag is how long after the end of a UTC day its pageviews are
+// usually in the analytics API
+const PageviewsLag = 12 * time.Hour
+
+// EditsLag is how long after the end of a month its edit statistics are
+// usually in the analytics API
+const EditsLag = 15 * 24 * time.Hour
+
+// Clock tells the current time. It can be replaced to test code that
+// depends on the date.
+type Clock interface {
+	Now() time.Time
+}
+
+// systemClock is the Clock of the computer the bot runs on
+type systemClock struct{}
+
+// Now outputs the current time
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// SystemClock is the Clock of the computer the bot runs on
+var SystemClock Clock = systemClock{}
+
+// DateAvailability tells which days the analytics API has data for, given
+// the current time and how long after a day the data usually comes in
+type DateAvailability struct {
+	// Clock tells the current time; SystemClock is used if it is nil, as
+	// it is at the time of the call
+	Clock Clock
+	// Lag is how long after the end of a UTC day, or of a month if Monthly
+	// is set, its data is available
+	Lag time.Duration
+	// Monthly is set if the data comes in a whole month at a time
+	Monthly bool
+}
+
+// PageviewsAvailability tells which days have pageview data
+var PageviewsAvailability = DateAvailability{nil, PageviewsLag, false}
+
+// EditsAvailability tells which days have edit statistics, which are
+// published once a month
+var EditsAvailability = DateAvailability{nil, EditsLag, true}
+
+// IsAvailable checks whether there is data for a day. Only the calendar
+// date of the day matters, not its time or timezone, so "June 5" is the
+// UTC day of June 5 for everyone.
+func (a DateAvailability) IsAvailable(day time.Time) bool {
+	return !a.LatestDay().Before(utcDay(day))
+}
+
+// LatestDay outputs the last UTC day there is data for
+func (a DateAvailability) LatestDay() time.Time {
+	ready := utcDay(a.now().UTC().Add(-a.Lag))
+	if a.Monthly {
+		// The last day of the month before
+		return ready.AddDate(0, 0, -ready.Day())
+	}
+	return ready.AddDate(0, 0, -1)
+}
+
+// LatestMonth outputs the last whole calendar month there is data for
+func (a DateAvailability) LatestMonth() DateRange {
+	end := a.LatestDay()
+	if next := end.AddDate(0, 0, 1); next.Day() != 1 {
+		// The month of the latest day isn't over yet
+		end = end.AddDate(0, 0, -end.Day())
+	}
+	return DateRange{end.AddDate(0, 0, 1-end.Day()), end}
+}
+
+// AvailableDay outputs the requested day, as a UTC date, if there is data
+// for it, or else the latest day there is data for. Changed is set if that
+// isn't the requested day.
+func (a DateAvailability) AvailableDay(day time.Time) (available time.Time, changed bool) {
+	if a.IsAvailable(day) {
+		return utcDay(day), false
+	}
+	return a.LatestDay(), true
+}
+
+// AvailableRange narrows a range of days down to the ones there is data
+// for, and to at most maxDays days, keeping the latest ones. Changed is set
+// if the range was narrowed; found is false if there is no data for any of
+// its days.
+func (a DateAvailability) AvailableRange(dateRange DateRange, maxDays int) (available DateRange, changed bool, found bool) {
+	available = dateRange
+	if latest := a.LatestDay(); available.End.After(latest) {
+		available.End = latest
+	}
+	if available.End.Before(available.Start) {
+		return dateRange, true, false
+	}
+	if maxDays > 0 && available.Days() > maxDays {
+		available.Start = available.End.AddDate(0, 0, 1-maxDays)
+	}
+	changed = !available.Start.Equal(dateRange.Start) || !available.End.Equal(dateRange.End)
+	return available, changed, true
+}
+
+// Output the current time according to the clock of the availability
+func (a DateAvailability) now() time.Time {
+	if a.Clock == nil {
+		return SystemClock.Now()
+	}
+	return a.Clock.Now()
+}
+
+// Check whether the calendar date of a time is before the current date in
+// UTC, according to the clock
+func isDateBeforeUTCToday(requestedDate time.Time, clock Clock) bool {
+	return utcDay(requestedDate).Before(utcDay(clock.Now().UTC()))
+}
@@ -0,0 +1,142 @@
+package wikipedia
+
+import (
+	"testing"
+	"time"
+)
+
+// fixedClock is a Clock that is always at the same time
+type fixedClock time.Time
+
+func (c fixedClock) Now() time.Time {
+	return time.Time(c)
+}
+
+func Test_isDateBeforeUTCToday(t *testing.T) {
+	tokyo := time.FixedZone("JST", 9*60*60)
+	tests := []struct {
+		name      string
+		requested time.Time
+		now       time.Time
+		expected  bool
+	}{
+		{"Day before", time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC), time.Date(2020, 6, 2, 0, 30, 0, 0, time.UTC), true},
+		{"Same day", time.Date(2020, 6, 2, 0, 0, 0, 0, time.UTC), time.Date(2020, 6, 2, 23, 59, 0, 0, time.UTC), false},
+		{"Day after", time.Date(2020, 6, 3, 0, 0, 0, 0, time.UTC), time.Date(2020, 6, 2, 12, 0, 0, 0, time.UTC), false},
+		{"Across a month", time.Date(2020, 6, 30, 0, 0, 0, 0, time.UTC), time.Date(2020, 7, 1, 8, 0, 0, 0, time.UTC), true},
+		{"Across a year", time.Date(2019, 12, 31, 0, 0, 0, 0, time.UTC), time.Date(2020, 1, 1, 8, 0, 0, 0, time.UTC), true},
+		{"Next month, earlier day", time.Date(2020, 7, 1, 0, 0, 0, 0, time.UTC), time.Date(2020, 6, 30, 8, 0, 0, 0, time.UTC), false},
+		{"Calendar date of another timezone", time.Date(2020, 6, 2, 1, 0, 0, 0, tokyo), time.Date(2020, 6, 1, 20, 0, 0, 0, time.UTC), false},
+		{"Clock in another timezone", time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC), time.Date(2020, 6, 2, 7, 0, 0, 0, tokyo), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if isBefore := isDateBeforeUTCToday(tt.requested, fixedClock(tt.now)); isBefore != tt.expected {
+				t.Errorf("isDateBeforeUTCToday() = %v, want %v", isBefore, tt.expected)
+			}
+		})
+	}
+}
+
+func Test_DateAvailability(t *testing.T) {
+	tests := []struct {
+		name              string
+		requested         time.Time
+		now               time.Time
+		expectedAvailable bool
+		expectedDay       string
+	}{
+		{"Long ago", time.Date(2020, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2020, 6, 2, 0, 0, 0, 0, time.UTC), true, "2020-03-01"},
+		{"Yesterday, data in", time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC), time.Date(2020, 6, 2, 13, 0, 0, 0, time.UTC), true, "2020-06-01"},
+		{"Yesterday, data not in yet", time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC), time.Date(2020, 6, 2, 11, 0, 0, 0, time.UTC), false, "2020-05-31"},
+		{"Today", time.Date(2020, 6, 2, 0, 0, 0, 0, time.UTC), time.Date(2020, 6, 2, 13, 0, 0, 0, time.UTC), false, "2020-06-01"},
+		{"Across a month", time.Date(2020, 7, 1, 0, 0, 0, 0, time.UTC), time.Date(2020, 7, 1, 8, 0, 0, 0, time.UTC), false, "2020-06-29"},
+		{"Across a year", time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2020, 1, 1, 20, 0, 0, 0, time.UTC), false, "2019-12-31"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			availability := DateAvailability{fixedClock(tt.now), PageviewsLag, false}
+			if available := availability.IsAvailable(tt.requested); available != tt.expectedAvailable {
+				t.Errorf("IsAvailable() = %v, want %v", available, tt.expectedAvailable)
+			}
+			day, changed := availability.AvailableDay(tt.requested)
+			if day.Format("2006-01-02") != tt.expectedDay || changed == tt.expectedAvailable {
+				t.Errorf("AvailableDay() = %s, %v, want %s, %v", day.Format("2006-01-02"), changed, tt.expectedDay, !tt.expectedAvailable)
+			}
+		})
+	}
+}
+
+func Test_AvailableRange(t *testing.T) {
+	availability := DateAvailability{fixedClock(time.Date(2020, 6, 2, 13, 0, 0, 0, time.UTC)), PageviewsLag, false}
+	day := func(month time.Month, day int) time.Time {
+		return time.Date(2020, month, day, 0, 0, 0, 0, time.UTC)
+	}
+	tests := []struct {
+		name            string
+		requested       DateRange
+		maxDays         int
+		expected        string
+		expectedChanged bool
+		expectedFound   bool
+	}{
+		{"Available", DateRange{day(5, 1), day(5, 31)}, 366, "May 01 2020 – May 31 2020", false, true},
+		{"Ends after the latest day", DateRange{day(5, 25), day(6, 7)}, 366, "May 25 2020 – Jun 01 2020", true, true},
+		{"Longer than allowed", DateRange{time.Date(1900, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2100, 12, 31, 0, 0, 0, 0, time.UTC)}, 366, "Jun 02 2019 – Jun 01 2020", true, true},
+		{"Not available at all", DateRange{day(6, 2), day(6, 9)}, 366, "Jun 02 2020 – Jun 09 2020", true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			available, changed, found := availability.AvailableRange(tt.requested, tt.maxDays)
+			if available.String() != tt.expected || changed != tt.expectedChanged || found != tt.expectedFound {
+				t.Errorf("AvailableRange() = %q, %v, %v, want %q, %v, %v", available, changed, found, tt.expected, tt.expectedChanged, tt.expectedFound)
+			}
+		})
+	}
+}
+
+func Test_DateAvailabilityMonthly(t *testing.T) {
+	tests := []struct {
+		name          string
+		now           time.Time
+		expectedDay   string
+		expectedMonth string
+	}{
+		{"Before the month is in", time.Date(2020, 6, 10, 0, 0, 0, 0, time.UTC), "2020-04-30", "Apr 01 2020 – Apr 30 2020"},
+		{"After the month is in", time.Date(2020, 6, 20, 0, 0, 0, 0, time.UTC), "2020-05-31", "May 01 2020 – May 31 2020"},
+		{"Across a year", time.Date(2020, 1, 5, 0, 0, 0, 0, time.UTC), "2019-11-30", "Nov 01 2019 – Nov 30 2019"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			availability := DateAvailability{fixedClock(tt.now), EditsLag, true}
+			if day := availability.LatestDay().Format("2006-01-02"); day != tt.expectedDay {
+				t.Errorf("LatestDay() = %s, want %s", day, tt.expectedDay)
+			}
+			if month := availability.LatestMonth(); month.String() != tt.expectedMonth || !month.IsWholeMonth() {
+				t.Errorf("LatestMonth() = %q, want %q", month, tt.expectedMonth)
+			}
+		})
+	}
+}
+
+func Test_LatestMonth(t *testing.T) {
+	availability := DateAvailability{fixedClock(time.Date(2020, 6, 2, 13, 0, 0, 0, time.UTC)), PageviewsLag, false}
+	if month := availability.LatestMonth(); month.String() != "May 01 2020 – May 31 2020" {
+		t.Errorf("LatestMonth() = %q, want May 2020", month)
+	}
+	availability.Clock = fixedClock(time.Date(2020, 6, 20, 13, 0, 0, 0, time.UTC))
+	if month := availability.LatestMonth(); month.String() != "May 01 2020 – May 31 2020" {
+		t.Errorf("LatestMonth() = %q, want May 2020", month)
+	}
+}
+
+func Test_AvailabilityFollowsSystemClock(t *testing.T) {
+	defer func(clock Clock) { SystemClock = clock }(SystemClock)
+	SystemClock = fixedClock(time.Date(2020, 6, 20, 13, 0, 0, 0, time.UTC))
+	if day := PageviewsAvailability.LatestDay().Format("2006-01-02"); day != "2020-06-19" {
+		t.Errorf("PageviewsAvailability.LatestDay() = %s, want 2020-06-19", day)
+	}
+	if day := EditsAvailability.LatestDay().Format("2006-01-02"); day != "2020-05-31" {
+		t.Errorf("EditsAvailability.LatestDay() = %s, want 2020-05-31", day)
+	}
+}
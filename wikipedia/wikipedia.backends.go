@@ -0,0 +1,337 @@
+package wikipedia
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Feature represents an optional capability of a wiki backend.
+// Commands that rely on a feature should check Backend.Supports
+// before using it, so wikis that don't offer it can be skipped cleanly.
+type Feature int
+
+const (
+	// FeatureRelated is the ability to list pages related to a given page
+	FeatureRelated Feature = iota
+	// FeaturePageviews is the availability of the analytics pageview data
+	FeaturePageviews
+	// FeatureEdits is the availability of the analytics edit statistics.
+	// Wikimedia publishes them for the same projects as the pageviews.
+	FeatureEdits
+)
+
+// Backend is the interface for a source of wiki pages that can
+// answer the "get" and "search" requests.
+type Backend interface {
+	// Name is the human readable name of the wiki, used in replies
+	Name() string
+	// Language is the language code of the content of the wiki
+	Language() string
+	// Supports reports whether the backend offers the given optional feature
+	Supports(feature Feature) bool
+	// Summary fetches the summary of the page with the given title
+	Summary(title string) []Page
+	// Related fetches the pages that are related to the given title
+	Related(title string) []Page
+	// Search fetches search results for the given term
+	Search(term string) []Page
+}
+
+// MediaWiki is a Backend for any wiki running MediaWiki, using its
+// Action API and, optionally, its RESTBase (rest_v1) endpoint.
+//
+// Only BaseURL is required; the other URLs are derived from it with
+// the usual MediaWiki layout unless they are given explicitly.
+type MediaWiki struct {
+	DisplayName string `json:"name"`
+	Lang        string `json:"lang"`
+	BaseURL     string `json:"baseURL"`
+	// ArticlePath is the URL of an article, with %s in place of the title
+	ArticlePath string `json:"articlePath"`
+	ActionAPI   string `json:"actionAPI"`
+	// RESTEndpoint is the RESTBase (rest_v1) endpoint. Leave empty if the
+	// wiki doesn't have one; related pages will then be unavailable.
+	RESTEndpoint string `json:"restEndpoint"`
+	// PageviewsProject is the project name in the Wikimedia analytics API,
+	// like "en.wikipedia". Leave empty if the wiki has no analytics.
+	PageviewsProject string `json:"pageviewsProject"`
+	// The context of the requests of the backend, if they can be cancelled
+	ctx context.Context
+}
+
+// Registered named wikis, selectable with wiki=name
+var wikis = map[string]Backend{}
+
+// The wiki used when a request doesn't ask for a wiki or a language.
+// When nil, the English Wikipedia is used.
+var defaultWiki Backend
+
+// NewMediaWiki creates a backend for the MediaWiki installation at the given base URL
+func NewMediaWiki(name string, baseURL string) *MediaWiki {
+	return &MediaWiki{DisplayName: name, BaseURL: baseURL}
+}
+
+// Wikipedia creates the backend for the Wikipedia of the given language
+func Wikipedia(lang string) *MediaWiki {
+	if len(lang) == 0 {
+		lang = "en"
+	}
+	return &MediaWiki{
+		DisplayName:      lang + ".Wikipedia",
+		Lang:             lang,
+		BaseURL:          fmt.Sprintf("https://%s.wikipedia.org", lang),
+		ArticlePath:      fmt.Sprintf(wikiBaseArticlePath, lang, "%s"),
+		ActionAPI:        fmt.Sprintf(wikiActionAPIendpoint, lang),
+		RESTEndpoint:     fmt.Sprintf(wikiRESTEndpoint, lang),
+		PageviewsProject: lang + ".wikipedia",
+	}
+}
+
+// RegisterWiki makes the given backend available under the given name,
+// so it can be requested with wiki=name
+func RegisterWiki(name string, wiki Backend) {
+	wikis[strings.ToLower(name)] = wiki
+}
+
+// SetDefaultWiki makes the given backend answer the requests that
+// don't ask for a specific wiki or language
+func SetDefaultWiki(wiki Backend) {
+	defaultWiki = wiki
+}
+
+// DefaultWiki outputs the wiki that answers the requests that don't ask
+// for a specific wiki or language, or nil if that is the English Wikipedia
+func DefaultWiki() Backend {
+	return defaultWiki
+}
+
+// Expressions like "wiki=corp"
+var wikiParameterRegexp = regexp.MustCompile("wiki=([[:alnum:]_.-]+)")
+
+// ParseWikiFromText looks for the wiki=name and lang=xx expressions and
+// outputs the matching backend. A registered wiki=name takes precedence;
+// otherwise, the Wikipedia of the requested language is used, or the
+// default wiki if no language was requested.
+func ParseWikiFromText(text string) (wiki Backend, remainingText string) {
+	match := wikiParameterRegexp.FindStringSubmatch(text)
+	if len(match) > 0 {
+		text = strings.TrimSpace(wikiParameterRegexp.ReplaceAllString(text, ""))
+	}
+	lang, remainingText := ParseLanguageFromText(text)
+
+	if len(match) > 0 {
+		if named, ok := wikis[strings.ToLower(match[1])]; ok {
+			return named, remainingText
+		}
+		toLog("ParseWikiFromText", "Unknown wiki requested: "+match[1])
+	}
+	if defaultWiki != nil && !languageParameterRegexp.MatchString(text) {
+		return defaultWiki, remainingText
+	}
+	return Wikipedia(lang), remainingText
+}
+
+// Output the registered wiki with the given name, or the
+// Wikipedia of the language with that code
+func wikiByName(name string) Backend {
+	if named, ok := wikis[strings.ToLower(name)]; ok {
+		return named
+	}
+	return Wikipedia(name)
+}
+
+// WikiParameter outputs the wiki=name or lang=xx expression that selects
+// the given backend in a request, so a follow-up request can use the same
+// wiki. It is empty for the Wikipedia used when nothing is requested.
+func WikiParameter(wiki Backend) string {
+	for name, named := range wikis {
+		if named == wiki {
+			return "wiki=" + name
+		}
+	}
+	if defaultWiki == nil && wiki.Language() == "en" {
+		return ""
+	}
+	return "lang=" + wiki.Language()
+}
+
+// Name outputs the human readable name of the wiki
+func (w *MediaWiki) Name() string {
+	if len(w.DisplayName) == 0 {
+		return w.BaseURL
+	}
+	return w.DisplayName
+}
+
+// Language outputs the content language of the wiki, defaulting to "en"
+func (w *MediaWiki) Language() string {
+	if len(w.Lang) == 0 {
+		return "en"
+	}
+	return w.Lang
+}
+
+// Supports reports whether the wiki offers the given feature
+func (w *MediaWiki) Supports(feature Feature) bool {
+	switch feature {
+	case FeatureRelated:
+		return len(w.RESTEndpoint) != 0
+	case FeaturePageviews, FeatureEdits:
+		// The analytics project has both the pageviews and the edits
+		return len(w.PageviewsProject) != 0
+	}
+	return false
+}
+
+// Summary fetches the summary of a specific page given by its title.
+// Wikis without a REST endpoint get the summary through the Action API.
+func (w *MediaWiki) Summary(title string) []Page {
+	safeTitle := prepTitleForURLQuery(title)
+	if len(w.RESTEndpoint) == 0 {
+		params := w.extractParams()
+		params.Del("exchars")
+		params.Add("titles", strings.TrimSpace(title))
+
+		url := w.actionAPIURL(params)
+		toLog("Summary", url)
+
+		body, readErr := w.fetch(url)
+		if readErr != nil {
+			return getNotFound()
+		}
+		return processActionAPIResult(body)
+	}
+
+	url := w.restURL(fmt.Sprintf(wikiRESTsummary, safeTitle))
+	toLog("Summary", url)
+
+	body, readErr := w.fetch(url)
+	if readErr != nil {
+		return getNotFound()
+	}
+
+	return processRESTApiResult(body, false)
+}
+
+// Related fetches the related pages for the given title
+func (w *MediaWiki) Related(title string) []Page {
+	if !w.Supports(FeatureRelated) {
+		return getNotFound()
+	}
+	safeTitle := prepTitleForURLQuery(title)
+
+	url := w.restURL(fmt.Sprintf(wikiRESTrelated, safeTitle))
+	toLog("Related", "URL: "+url)
+
+	body, readErr := w.fetch(url)
+	if readErr != nil {
+		return getNotFound()
+	}
+
+	return processRESTApiResult(body, true)
+}
+
+// Search fetches search results from the wiki given the search string
+func (w *MediaWiki) Search(term string) []Page {
+	params := w.extractParams()
+
+	params.Add("generator", "search")
+	params.Add("gsrlimit", "5")
+	// params.Add("gsrwhat", "text")
+	params.Add("gsrwhat", "nearmatch")
+	params.Add("gsrsearch", term)
+
+	url := w.actionAPIURL(params)
+	toLog("Search", "URL: "+url)
+
+	body, readErr := w.fetch(url)
+	if readErr != nil {
+		return getNotFound()
+	}
+
+	return processActionAPIResult(body)
+}
+
+// TopPageviews fetches the top articles by pageview for the given date,
+// narrowed down to an access method or a country by the options
+func (w *MediaWiki) TopPageviews(t time.Time, options TopPageviewsOptions) []PagelistPage {
+	if !w.Supports(FeaturePageviews) {
+		return []PagelistPage{{"Not found.", "", 0, ""}}
+	}
+	// The country lists have all wikis in them, and are ranked here
+	if len(options.Country) != 0 {
+		return rankTopPageviews(w.topPageviewsTotals(NewDateRange(t, t), options), w)
+	}
+
+	url := fmt.Sprintf(wikiAnalyticsPageviewsEndpoint, w.PageviewsProject, options.access(), t.Year(), int(t.Month()), t.Day())
+	toLog("TopPageviews", "URL: "+url)
+
+	body, readErr := fetchFromAPI(url)
+	if readErr != nil {
+		return []PagelistPage{{"Not found.", "", 0, ""}}
+	}
+
+	return processAnalyticsPageviews(body, w)
+}
+
+// ArticleURL outputs the URL of the article with the given title
+func (w *MediaWiki) ArticleURL(title string) string {
+	articlePath := w.ArticlePath
+	if len(articlePath) == 0 {
+		articlePath = strings.TrimRight(w.BaseURL, "/") + "/wiki/%s"
+	}
+	return fmt.Sprintf(articlePath, url.PathEscape(strings.ReplaceAll(title, " ", "_")))
+}
+
+// Output a copy of the backend whose requests are cancelled when the
+// context is done
+func (w *MediaWiki) withContext(ctx context.Context) *MediaWiki {
+	copied := *w
+	copied.ctx = ctx
+	return &copied
+}
+
+// Fetch from the API in the context of the backend
+func (w *MediaWiki) fetch(url string) (body []byte, err error) {
+	if w.ctx == nil {
+		return fetchFromAPI(url)
+	}
+	return fetchFromAPIContext(w.ctx, url)
+}
+
+// Build the full REST url for the given path, whether or not the configured
+// endpoint ends with a slash
+func (w *MediaWiki) restURL(path string) string {
+	return strings.TrimSuffix(w.RESTEndpoint, "/") + "/" + path
+}
+
+// Build the full Action API url for the given parameters
+func (w *MediaWiki) actionAPIURL(params url.Values) string {
+	endpoint := w.ActionAPI
+	if len(endpoint) == 0 {
+		endpoint = strings.TrimRight(w.BaseURL, "/") + "/w/api.php"
+	}
+	return endpoint + "?" + params.Encode()
+}
+
+// Output the Action API parameters that fetch pages with their
+// intro extract, image and url
+func (w *MediaWiki) extractParams() url.Values {
+	params := url.Values{}
+
+	params.Add("action", "query")
+	params.Add("format", "json")
+	params.Add("prop", "extracts|pageimages|info|pageprops|coordinates")
+	params.Add("ppprop", "wikibase_item")
+	params.Add("redirects", "1")
+	params.Add("exchars", "250")
+	params.Add("exlimit", "5")
+	params.Add("exintro", "1")
+	params.Add("explaintext", "1")
+	params.Add("inprop", "url")
+	return params
+}
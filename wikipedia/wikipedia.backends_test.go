@@ -0,0 +1,77 @@
+package wikipedia
+
+import (
+	"testing"
+)
+
+func Test_ParseWikiFromText(t *testing.T) {
+	RegisterWiki("Corp", NewMediaWiki("Corp Wiki", "https://wiki.example.com/"))
+	tests := []struct {
+		name         string
+		text         string
+		expectedWiki string
+		expectedText string
+	}{
+		{"No parameters", "foo bar", "en.Wikipedia", "foo bar"},
+		{"Language parameter", "foo lang=he bar", "he.Wikipedia", "foo  bar"},
+		{"Registered wiki", "wiki=corp foo bar", "Corp Wiki", "foo bar"},
+		{"Unknown wiki", "foo wiki=unknown lang=fr", "fr.Wikipedia", "foo"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wiki, text := ParseWikiFromText(tt.text)
+			if wiki.Name() != tt.expectedWiki || text != tt.expectedText {
+				t.Errorf("ParseWikiFromText() = %v, %q, want %v, %q", wiki.Name(), text, tt.expectedWiki, tt.expectedText)
+			}
+		})
+	}
+}
+
+func Test_MediaWikiURLs(t *testing.T) {
+	wiki := NewMediaWiki("Corp Wiki", "https://wiki.example.com/")
+	if got := wiki.ArticleURL("Foo bar"); got != "https://wiki.example.com/wiki/Foo_bar" {
+		t.Errorf("ArticleURL() = %v", got)
+	}
+	if wiki.Supports(FeatureRelated) || wiki.Supports(FeaturePageviews) || wiki.Supports(FeatureEdits) {
+		t.Errorf("Supports() should be false without REST and analytics endpoints")
+	}
+	for _, endpoint := range []string{"https://wiki.example.com/api/rest_v1", "https://wiki.example.com/api/rest_v1/"} {
+		wiki.RESTEndpoint = endpoint
+		if got := wiki.restURL("page/related/Foo"); got != "https://wiki.example.com/api/rest_v1/page/related/Foo" {
+			t.Errorf("restURL() = %v", got)
+		}
+	}
+	if !Wikipedia("he").Supports(FeaturePageviews) || !Wikipedia("he").Supports(FeatureEdits) {
+		t.Errorf("Supports() should be true for Wikipedia pageviews and edits")
+	}
+}
+
+func Test_ParseWikiFromTextDefault(t *testing.T) {
+	SetDefaultWiki(NewMediaWiki("Default Wiki", "https://default.example.com/"))
+	defer SetDefaultWiki(nil)
+
+	if wiki, _ := ParseWikiFromText("foo"); wiki.Name() != "Default Wiki" {
+		t.Errorf("ParseWikiFromText() = %v, want the default wiki", wiki.Name())
+	}
+	if wiki, _ := ParseWikiFromText("foo lang="); wiki.Name() != "Default Wiki" {
+		t.Errorf("ParseWikiFromText() = %v, want the default wiki for an empty lang=", wiki.Name())
+	}
+	if wiki, _ := ParseWikiFromText("foo lang=de"); wiki.Name() != "de.Wikipedia" {
+		t.Errorf("ParseWikiFromText() = %v, want de.Wikipedia", wiki.Name())
+	}
+}
+
+func Test_WikiParameter(t *testing.T) {
+	corp := NewMediaWiki("Corp Wiki", "https://wiki.example.com/")
+	RegisterWiki("Corp", corp)
+
+	if parameter := WikiParameter(corp); parameter != "wiki=corp" {
+		t.Errorf("WikiParameter() = %q, want wiki=corp", parameter)
+	}
+	if parameter := WikiParameter(Wikipedia("he")); parameter != "lang=he" {
+		t.Errorf("WikiParameter() = %q, want lang=he", parameter)
+	}
+	if parameter := WikiParameter(Wikipedia("en")); parameter != "" {
+		t.Errorf("WikiParameter() = %q, want no parameter", parameter)
+	}
+}
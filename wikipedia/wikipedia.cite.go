@@ -0,0 +1,187 @@
+package wikipedia
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// CitationStyles are the supported citation styles, the first being the default
+var CitationStyles = []string{"apa", "mla", "chicago", "bibtex", "csl-json"}
+
+// Other names people use for the citation styles
+var citationStyleAliases = map[string]string{
+	"bib":  "bibtex",
+	"csl":  "csl-json",
+	"json": "csl-json",
+}
+
+// Citation is a reference to a specific revision of an article
+type Citation struct {
+	Title string
+	// Site is the name of the wiki, like "Wikipedia"
+	Site string
+	// Publisher is the organization behind the wiki, if known
+	Publisher  string
+	URL        string
+	RevisionID int
+	LastEdited time.Time
+	Accessed   time.Time
+}
+
+// ParseCitationStyle splits a citation style from the end of the text,
+// like "Tokyo mla". The default style is used if there is none.
+func ParseCitationStyle(text string) (style string, remainingText string) {
+	text = strings.TrimSpace(text)
+	words := strings.Fields(text)
+	if len(words) < 2 {
+		return CitationStyles[0], text
+	}
+	last := strings.ToLower(words[len(words)-1])
+	if alias, ok := citationStyleAliases[last]; ok {
+		last = alias
+	}
+	for _, style := range CitationStyles {
+		if style == last {
+			return style, strings.TrimSpace(text[:strings.LastIndex(text, words[len(words)-1])])
+		}
+	}
+	return CitationStyles[0], text
+}
+
+// FetchCitation builds the citation of the current revision of an article,
+// in the style named at the end of the text. The article is found the same
+// way FetchGetGeneralTerm does.
+func FetchCitation(text string) (citation Citation, style string, wiki Backend, actualTitle string, found bool) {
+	wiki, text = ParseWikiFromText(text)
+	style, actualTitle = ParseCitationStyle(text)
+
+	mediaWiki, ok := wiki.(*MediaWiki)
+	if !ok || len(actualTitle) == 0 {
+		return citation, style, wiki, actualTitle, false
+	}
+	results, _ := getGeneralTerm(wiki, actualTitle, false)
+	if len(results) != 1 || results[0].Title == "Not found." {
+		return citation, style, wiki, actualTitle, false
+	}
+	page := results[0]
+
+	// The summary has the revision it is from; other wikis are asked for it
+	revisionID, lastEdited := page.RevisionID, page.LastEdited
+	if revisionID == 0 || lastEdited.IsZero() {
+		metadata, found := mediaWiki.Metadata(page.Title)
+		if !found || metadata.RevisionID == 0 {
+			return citation, style, wiki, actualTitle, false
+		}
+		revisionID, lastEdited = metadata.RevisionID, metadata.LastEdited
+	}
+
+	citation = Citation{
+		Title:      page.Title,
+		Site:       mediaWiki.Name(),
+		URL:        mediaWiki.PermanentURL(page.Title, revisionID),
+		RevisionID: revisionID,
+		LastEdited: lastEdited.UTC(),
+		Accessed:   time.Now().UTC(),
+	}
+	if strings.HasSuffix(mediaWiki.BaseURL, ".wikipedia.org") {
+		citation.Site = "Wikipedia"
+		citation.Publisher = "Wikimedia Foundation"
+	}
+	return citation, style, wiki, actualTitle, true
+}
+
+// Format outputs the citation in one of the CitationStyles
+func (c Citation) Format(style string) string {
+	switch style {
+	case "mla":
+		publisher := ""
+		if len(c.Publisher) != 0 {
+			publisher = c.Publisher + ", "
+		}
+		return fmt.Sprintf("\"%s.\" %s, %s%s, %s. Accessed %s.",
+			c.Title, c.Site, publisher, mlaDate(c.LastEdited), c.URL, mlaDate(c.Accessed))
+	case "chicago":
+		return fmt.Sprintf("%s contributors. \"%s.\" %s. Last modified %s. Accessed %s. %s.",
+			c.Site, c.Title, c.Site, c.LastEdited.Format("January 2, 2006, 15:04 MST"), c.Accessed.Format("January 2, 2006"), c.URL)
+	case "bibtex":
+		return fmt.Sprintf("@misc{%s,\n  author = \"{%s contributors}\",\n  title = \"%s --- {%s}\",\n  year = \"%d\",\n  url = \"%s\",\n  note = \"Revision %d, last edited %s; accessed %s\"\n}",
+			c.bibtexKey(), bibtexEscape(c.Site), bibtexEscape(c.Title), bibtexEscape(c.Site), c.LastEdited.Year(), c.URL,
+			c.RevisionID, c.LastEdited.Format("2 January 2006, 15:04 MST"), c.Accessed.Format("2 January 2006"))
+	case "csl-json":
+		return c.cslJSON()
+	}
+	return fmt.Sprintf("%s. (%s). In %s. %s",
+		strings.TrimSuffix(c.Title, "."), c.LastEdited.Format("2006, January 2"), c.Site, c.URL)
+}
+
+// Format a date the way MLA does, like "30 Apr. 2020"
+func mlaDate(date time.Time) string {
+	month := date.Format("Jan.")
+	switch date.Month() {
+	case time.May, time.June, time.July:
+		month = date.Format("January")
+	case time.September:
+		month = "Sept."
+	}
+	return fmt.Sprintf("%d %s %d", date.Day(), month, date.Year())
+}
+
+// Characters that can't be used in BibTeX keys
+var bibtexKeyRegexp = regexp.MustCompile(`[^[:alnum:]]+`)
+
+// Output the BibTeX key of the citation, like "wiki:Tokyo_953000000"
+func (c Citation) bibtexKey() string {
+	return fmt.Sprintf("wiki:%s_%d", strings.Trim(bibtexKeyRegexp.ReplaceAllString(c.Title, "_"), "_"), c.RevisionID)
+}
+
+// Escape the characters with a special meaning in BibTeX values
+func bibtexEscape(text string) string {
+	return strings.NewReplacer(`\`, `\textbackslash{}`, `"`, `{"}`, "&", `\&`, "%", `\%`, "$", `\$`, "#", `\#`, "_", `\_`, "{", `\{`, "}", `\}`).Replace(text)
+}
+
+// cslDate is a date in CSL-JSON
+type cslDate struct {
+	DateParts [][]int `json:"date-parts"`
+}
+
+// cslItem is a single item in CSL-JSON, with the fields a wiki article uses
+type cslItem struct {
+	ID             string  `json:"id"`
+	Type           string  `json:"type"`
+	Title          string  `json:"title"`
+	ContainerTitle string  `json:"container-title"`
+	Publisher      string  `json:"publisher,omitempty"`
+	URL            string  `json:"URL"`
+	Issued         cslDate `json:"issued"`
+	Accessed       cslDate `json:"accessed"`
+	Note           string  `json:"note"`
+}
+
+// Output the citation as a CSL-JSON list with a single item
+func (c Citation) cslJSON() string {
+	toDate := func(date time.Time) cslDate {
+		return cslDate{[][]int{{date.Year(), int(date.Month()), date.Day()}}}
+	}
+	items := []cslItem{{
+		ID:             c.bibtexKey(),
+		Type:           "entry-encyclopedia",
+		Title:          c.Title,
+		ContainerTitle: c.Site,
+		Publisher:      c.Publisher,
+		URL:            c.URL,
+		Issued:         toDate(c.LastEdited),
+		Accessed:       toDate(c.Accessed),
+		Note:           fmt.Sprintf("Revision %d", c.RevisionID),
+	}}
+	// URLs are easier to copy without their & escaped
+	output := bytes.Buffer{}
+	encoder := json.NewEncoder(&output)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(items)
+	return strings.TrimSpace(output.String())
+}
@@ -0,0 +1,64 @@
+package wikipedia
+
+import (
+	"testing"
+	"time"
+)
+
+var testCitation = Citation{
+	Title:      "Tokyo",
+	Site:       "Wikipedia",
+	Publisher:  "Wikimedia Foundation",
+	URL:        "https://en.wikipedia.org/w/index.php?oldid=953000000&title=Tokyo",
+	RevisionID: 953000000,
+	LastEdited: time.Date(2020, 4, 30, 8, 15, 0, 0, time.UTC),
+	Accessed:   time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC),
+}
+
+func Test_ParseCitationStyle(t *testing.T) {
+	tests := []struct {
+		name          string
+		text          string
+		expectedStyle string
+		expectedText  string
+	}{
+		{"Default style", "Tokyo", "apa", "Tokyo"},
+		{"Named style", "Tokyo Tower MLA", "mla", "Tokyo Tower"},
+		{"Alias", "Tokyo bib", "bibtex", "Tokyo"},
+		{"Style name alone is a title", "Chicago", "apa", "Chicago"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			style, text := ParseCitationStyle(tt.text)
+			if style != tt.expectedStyle || text != tt.expectedText {
+				t.Errorf("ParseCitationStyle() = %q, %q, want %q, %q", style, text, tt.expectedStyle, tt.expectedText)
+			}
+		})
+	}
+}
+
+func Test_CitationFormat(t *testing.T) {
+	tests := []struct {
+		style    string
+		expected string
+	}{
+		{"apa", "Tokyo. (2020, April 30). In Wikipedia. https://en.wikipedia.org/w/index.php?oldid=953000000&title=Tokyo"},
+		{"mla", "\"Tokyo.\" Wikipedia, Wikimedia Foundation, 30 Apr. 2020, https://en.wikipedia.org/w/index.php?oldid=953000000&title=Tokyo. Accessed 1 May 2020."},
+		{"chicago", "Wikipedia contributors. \"Tokyo.\" Wikipedia. Last modified April 30, 2020, 08:15 UTC. Accessed May 1, 2020. https://en.wikipedia.org/w/index.php?oldid=953000000&title=Tokyo."},
+		{"bibtex", "@misc{wiki:Tokyo_953000000,\n  author = \"{Wikipedia contributors}\",\n  title = \"Tokyo --- {Wikipedia}\",\n  year = \"2020\",\n  url = \"https://en.wikipedia.org/w/index.php?oldid=953000000&title=Tokyo\",\n  note = \"Revision 953000000, last edited 30 April 2020, 08:15 UTC; accessed 1 May 2020\"\n}"},
+		{"csl-json", "[\n  {\n    \"id\": \"wiki:Tokyo_953000000\",\n    \"type\": \"entry-encyclopedia\",\n    \"title\": \"Tokyo\",\n    \"container-title\": \"Wikipedia\",\n    \"publisher\": \"Wikimedia Foundation\",\n    \"URL\": \"https://en.wikipedia.org/w/index.php?oldid=953000000&title=Tokyo\",\n    \"issued\": {\n      \"date-parts\": [\n        [\n          2020,\n          4,\n          30\n        ]\n      ]\n    },\n    \"accessed\": {\n      \"date-parts\": [\n        [\n          2020,\n          5,\n          1\n        ]\n      ]\n    },\n    \"note\": \"Revision 953000000\"\n  }\n]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.style, func(t *testing.T) {
+			if text := testCitation.Format(tt.style); text != tt.expected {
+				t.Errorf("Format() = %q, want %q", text, tt.expected)
+			}
+		})
+	}
+}
+
+func Test_bibtexEscape(t *testing.T) {
+	if text := bibtexEscape("AT&T 100% C_D"); text != `AT\&T 100\% C\_D` {
+		t.Errorf("bibtexEscape() = %q", text)
+	}
+}
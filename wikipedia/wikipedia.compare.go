@@ -0,0 +1,118 @@
+package wikipedia
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The most articles that can be compared at once
+const compareLimit = 6
+
+// Separates the articles to compare, like "Tokyo vs Osaka"
+var compareSeparatorRegexp = regexp.MustCompile(`(?i)\s+(?:vs\.?|versus)\s+`)
+
+// ComparedArticle is the pageviews of one of the articles in a comparison
+type ComparedArticle struct {
+	// Requested are the titles that were asked for and lead to this
+	// article, like a redirect and the article itself
+	Requested []string
+	Views     ArticleViews
+	Stats     PageviewStats
+	Found     bool
+}
+
+// PageviewsComparison is the pageviews of several articles over the same
+// days, so the points of all articles line up
+type PageviewsComparison struct {
+	Query    PageviewsQuery
+	Articles []ComparedArticle
+}
+
+// ParseComparedTitles splits the titles to compare, like
+// "Tokyo vs Osaka vs. Kyoto". Empty and repeated titles are left out,
+// and only the first few are kept.
+func ParseComparedTitles(text string) (titles []string) {
+	seen := map[string]bool{}
+	for _, title := range compareSeparatorRegexp.Split(strings.TrimSpace(text), -1) {
+		title = strings.TrimSpace(title)
+		if len(title) == 0 || seen[strings.ToLower(title)] {
+			continue
+		}
+		seen[strings.ToLower(title)] = true
+		titles = append(titles, title)
+	}
+	if len(titles) > compareLimit {
+		titles = titles[:compareLimit]
+	}
+	return titles
+}
+
+// FetchPageviewsComparison fetches the pageviews of all the articles in the
+// text at the same time, for the range and options at its end, like
+// "Tokyo vs Osaka last 90 days". Each article is found the same way
+// FetchGetGeneralTerm does, so titles that redirect to the same article are
+// counted once. Relative dates are read from now, like in ParseDate, and
+// the range is cut down like in ParsePageviewsQuery, since it is fetched
+// once per article. Found is false if none of the articles were found.
+func FetchPageviewsComparison(text string, now time.Time) (comparison PageviewsComparison, wiki Backend, found bool) {
+	wiki, text = ParseWikiFromText(text)
+	query, text := ParsePageviewsQuery(text, now)
+	comparison.Query = query
+
+	mediaWiki, ok := wiki.(*MediaWiki)
+	if !ok || !mediaWiki.Supports(FeaturePageviews) {
+		return comparison, wiki, false
+	}
+
+	titles := ParseComparedTitles(text)
+	fetched := make([]ComparedArticle, len(titles))
+	var wait sync.WaitGroup
+	for index, title := range titles {
+		wait.Add(1)
+		go func(index int, title string) {
+			defer wait.Done()
+			views, found := fetchArticleViews(mediaWiki, title, query)
+			fetched[index] = ComparedArticle{[]string{title}, views, views.Stats(), found}
+		}(index, title)
+	}
+	wait.Wait()
+
+	comparison.Articles = mergeComparedArticles(fetched)
+	for _, article := range comparison.Articles {
+		found = found || article.Found
+	}
+	return comparison, wiki, found
+}
+
+// Ranking outputs the articles that were found, with the most viewed first
+func (c PageviewsComparison) Ranking() (ranking []ComparedArticle) {
+	for _, article := range c.Articles {
+		if article.Found {
+			ranking = append(ranking, article)
+		}
+	}
+	sort.SliceStable(ranking, func(i, j int) bool {
+		return ranking[i].Stats.Total > ranking[j].Stats.Total
+	})
+	return ranking
+}
+
+// Output the articles with the ones that lead to the same page merged
+// into the first of them, keeping their order
+func mergeComparedArticles(articles []ComparedArticle) (merged []ComparedArticle) {
+	positions := map[string]int{}
+	for _, article := range articles {
+		if article.Found {
+			if position, ok := positions[article.Views.Page.Title]; ok {
+				merged[position].Requested = append(merged[position].Requested, article.Requested...)
+				continue
+			}
+			positions[article.Views.Page.Title] = len(merged)
+		}
+		merged = append(merged, article)
+	}
+	return merged
+}
@@ -0,0 +1,70 @@
+package wikipedia
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func Test_ParseComparedTitles(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		expected []string
+	}{
+		{"Two titles", "Tokyo vs Osaka", []string{"Tokyo", "Osaka"}},
+		{"Separators", "Tokyo vs. Osaka VS Kyoto versus Nara", []string{"Tokyo", "Osaka", "Kyoto", "Nara"}},
+		{"Repeated title", "Tokyo vs tokyo vs Osaka", []string{"Tokyo", "Osaka"}},
+		{"Separator in a word", "Elvis vs Vsevolod", []string{"Elvis", "Vsevolod"}},
+		{"Too many titles", "A vs B vs C vs D vs E vs F vs G", []string{"A", "B", "C", "D", "E", "F"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if titles := ParseComparedTitles(tt.text); !reflect.DeepEqual(titles, tt.expected) {
+				t.Errorf("ParseComparedTitles() = %q, want %q", titles, tt.expected)
+			}
+		})
+	}
+}
+
+func Test_ComparisonRange(t *testing.T) {
+	now := time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)
+	query, text := ParsePageviewsQuery("Tokyo vs Osaka last 99999999 years", now)
+	if query.Range.String() != "Oct 10 2016 – Oct 17 2026" || len(query.buckets()) > PageviewsRangeMaxDays {
+		t.Errorf("ParsePageviewsQuery() = %q with %d days, want Oct 10 2016 – Oct 17 2026", query.Range, len(query.buckets()))
+	}
+	if previous := query.Previous().Range; previous.Days() != query.Range.Days() {
+		t.Errorf("Previous() = %q, want %d days", previous, query.Range.Days())
+	}
+	if titles := ParseComparedTitles(text); !reflect.DeepEqual(titles, []string{"Tokyo", "Osaka"}) {
+		t.Errorf("ParseComparedTitles() = %q, want Tokyo and Osaka", titles)
+	}
+}
+
+func Test_mergeComparedArticles(t *testing.T) {
+	article := func(requested string, title string, total int, found bool) ComparedArticle {
+		return ComparedArticle{
+			Requested: []string{requested},
+			Views:     ArticleViews{Page: Page{Title: title}},
+			Stats:     PageviewStats{Total: total},
+			Found:     found,
+		}
+	}
+	comparison := PageviewsComparison{Articles: mergeComparedArticles([]ComparedArticle{
+		article("NYC", "New York City", 500, true),
+		article("Tokyo", "Tokyo", 900, true),
+		article("Qwxzv", "", 0, false),
+		article("New York City", "New York City", 500, true),
+	})}
+
+	if len(comparison.Articles) != 3 || !reflect.DeepEqual(comparison.Articles[0].Requested, []string{"NYC", "New York City"}) {
+		t.Errorf("mergeComparedArticles() = %+v", comparison.Articles)
+	}
+	ranking := []string{}
+	for _, article := range comparison.Ranking() {
+		ranking = append(ranking, article.Views.Page.Title)
+	}
+	if !reflect.DeepEqual(ranking, []string{"Tokyo", "New York City"}) {
+		t.Errorf("Ranking() = %q", ranking)
+	}
+}
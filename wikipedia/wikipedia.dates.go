@@ -0,0 +1,66 @@
+package wikipedia
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/araddon/dateparse"
+)
+
+// Days like "today", "yesterday" or "3 days ago"
+var relativeDayRegexp = regexp.MustCompile(`(?i)^(?:(today)|(yesterday)|(\d+)\s+days?\s+ago)$`)
+
+// Days without a year, like "June 5", "Jun. 5" or "5 June"
+var monthDayRegexp = regexp.MustCompile(`(?i)^(?:[a-z]+\.?\s+\d{1,2}|\d{1,2}\s+[a-z]+\.?)$`)
+
+// ParseDate parses the given string into a day, read in the timezone of now,
+// which "today", "yesterday" and "3 days ago" are also counted from. An
+// empty string is today. A day without a year, like "June 5", is the last
+// such day up to today. The error is set if the string isn't a date.
+func ParseDate(datestring string, now time.Time) (parsed time.Time, err error) {
+	datestring = strings.Join(strings.Fields(datestring), " ")
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	if len(datestring) == 0 {
+		return today, nil
+	}
+
+	if match := relativeDayRegexp.FindStringSubmatch(datestring); match != nil {
+		switch {
+		case len(match[1]) != 0:
+			return today, nil
+		case len(match[2]) != 0:
+			return today.AddDate(0, 0, -1), nil
+		}
+		days, _ := strconv.Atoi(match[3])
+		return today.AddDate(0, 0, -days), nil
+	}
+
+	if monthDayRegexp.MatchString(datestring) {
+		parsed, err = dateparse.ParseIn(datestring+" "+strconv.Itoa(today.Year()), now.Location())
+		if err == nil && parsed.After(today) {
+			parsed = parsed.AddDate(-1, 0, 0)
+		}
+		return parsed, err
+	}
+
+	parsed, err = dateparse.ParseIn(datestring, now.Location())
+	if err == nil && parsed.Year() == 0 {
+		err = errors.New("no year in " + datestring)
+	}
+	return parsed, err
+}
+
+// ParseDateOrRange parses the given string into a range of days, like
+// ParseDateRange, or else into a single day, like ParseDate, and isRange is
+// false. The error is set if the string is neither.
+func ParseDateOrRange(datestring string, now time.Time) (dateRange DateRange, isRange bool, err error) {
+	dateRange, remainingText, found := ParseDateRange(datestring, now)
+	if found && len(remainingText) == 0 {
+		return dateRange, true, nil
+	}
+	parsed, err := ParseDate(datestring, now)
+	return NewDateRange(parsed, parsed), false, err
+}
@@ -0,0 +1,74 @@
+package wikipedia
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_ParseDate(t *testing.T) {
+	tokyo := time.FixedZone("JST", 9*60*60)
+	// Still March 14 in UTC
+	now := time.Date(2020, 3, 15, 2, 0, 0, 0, tokyo)
+	tests := []struct {
+		name          string
+		text          string
+		expected      string
+		expectedError bool
+	}{
+		{"Empty", "", "2020-03-15", false},
+		{"Today", "today", "2020-03-15", false},
+		{"Yesterday", "Yesterday", "2020-03-14", false},
+		{"Days ago", "3 days ago", "2020-03-12", false},
+		{"Full date", "March 1 2020", "2020-03-01", false},
+		{"ISO date", "2019-12-31", "2019-12-31", false},
+		{"Day without a year", "March 1", "2020-03-01", false},
+		{"Day without a year, after today", "June 5", "2019-06-05", false},
+		{"Short month without a year", "Jun 5", "2019-06-05", false},
+		{"Day before the month", "5 June", "2019-06-05", false},
+		{"Not a date", "tomorrowish", "", true},
+		{"Day out of range", "June 31 2020", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parsed, err := ParseDate(tt.text, now)
+			if (err != nil) != tt.expectedError {
+				t.Fatalf("ParseDate() error = %v, want error %v", err, tt.expectedError)
+			}
+			if err == nil && (parsed.Format("2006-01-02") != tt.expected || parsed.Location() != tokyo) {
+				t.Errorf("ParseDate() = %v, want %s in %v", parsed, tt.expected, tokyo)
+			}
+		})
+	}
+}
+
+func Test_ParseDateOrRange(t *testing.T) {
+	now := time.Date(2020, 3, 15, 18, 30, 0, 0, time.UTC)
+	tests := []struct {
+		name            string
+		text            string
+		expected        string
+		expectedIsRange bool
+		expectedError   bool
+	}{
+		{"Range", "last week", "Mar 08 2020 – Mar 14 2020", true, false},
+		{"Day", "March 1 2020", "Mar 01 2020", false, false},
+		{"Not a date", "next blue moon", "", false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dateRange, isRange, err := ParseDateOrRange(tt.text, now)
+			if (err != nil) != tt.expectedError || isRange != tt.expectedIsRange || (err == nil && dateRange.String() != tt.expected) {
+				t.Errorf("ParseDateOrRange() = %q, %v, %v, want %q, %v, error %v", dateRange, isRange, err, tt.expected, tt.expectedIsRange, tt.expectedError)
+			}
+		})
+	}
+}
+
+func Test_FetchWithUnreadableDate(t *testing.T) {
+	if list := FetchTopPageviews("the day after forever", "en"); len(list) != 1 || list[0].Title != "Not found." {
+		t.Errorf("FetchTopPageviews() = %v, want not found", list)
+	}
+	if _, found := FetchTopEdits("the day after forever", "en"); found {
+		t.Errorf("FetchTopEdits() found edits for an unreadable date")
+	}
+}
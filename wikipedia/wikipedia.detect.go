@@ -0,0 +1,181 @@
+package wikipedia
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// scriptLanguage maps a Unicode script to the Wikipedia most likely
+// meant by a query written in it
+type scriptLanguage struct {
+	script *unicode.RangeTable
+	name   string
+	lang   string
+}
+
+// Scripts that point at a single language on their own. Han is handled
+// separately, since it is shared by Chinese and Japanese.
+var scriptLanguages = []scriptLanguage{
+	{unicode.Hebrew, "Hebrew", "he"},
+	{unicode.Arabic, "Arabic", "ar"},
+	{unicode.Cyrillic, "Cyrillic", "ru"},
+	{unicode.Greek, "Greek", "el"},
+	{unicode.Hangul, "Hangul", "ko"},
+	{unicode.Thai, "Thai", "th"},
+	{unicode.Devanagari, "Devanagari", "hi"},
+	{unicode.Armenian, "Armenian", "hy"},
+	{unicode.Georgian, "Georgian", "ka"},
+	{unicode.Bengali, "Bengali", "bn"},
+	{unicode.Tamil, "Tamil", "ta"},
+}
+
+// Letters that set a language apart from others sharing its script
+var scriptVariants = map[string][]struct {
+	letters string
+	lang    string
+	name    string
+}{
+	"ar": {{"پچژگکی", "fa", "Persian"}, {"ٹڈڑںےھ", "ur", "Urdu"}},
+	"ru": {{"іїєґ", "uk", "Ukrainian"}, {"ў", "be", "Belarusian"}},
+}
+
+// latinProfile is the statistics for recognizing a language written in
+// the Latin script: its distinctive letters, common short words and
+// common letter trigrams
+type latinProfile struct {
+	lang     string
+	name     string
+	letters  string
+	words    []string
+	trigrams []string
+}
+
+var latinProfiles = []latinProfile{
+	{"en", "English", "", []string{"the", "and", "of", "in", "is", "to", "with", "for"}, []string{"the", "and", "ing", "ion", "tio", "ent", "her"}},
+	{"de", "German", "äöüß", []string{"der", "die", "das", "und", "ist", "von", "mit", "für", "ein", "eine"}, []string{"sch", "ein", "ich", "der", "und", "cht", "ung"}},
+	{"fr", "French", "àâæçèêëîïôœùûÿ", []string{"le", "la", "les", "des", "du", "et", "est", "une", "pour", "dans"}, []string{"les", "ent", "que", "eau", "aux", "ais", "oir"}},
+	{"es", "Spanish", "ñ¿¡", []string{"el", "la", "los", "las", "del", "y", "es", "una", "por", "para", "con"}, []string{"los", "ado", "ión", "nte", "ció", "las", "dad"}},
+	{"pt", "Portuguese", "ãõ", []string{"o", "os", "do", "da", "dos", "das", "e", "um", "uma", "não", "com"}, []string{"ção", "ões", "ado", "dos", "nto", "ade", "ais"}},
+	{"it", "Italian", "ìò", []string{"il", "lo", "gli", "di", "che", "un", "una", "per", "con", "della"}, []string{"che", "ell", "zio", "are", "gli", "ato", "ion"}},
+	{"nl", "Dutch", "", []string{"de", "het", "een", "en", "van", "is", "niet", "met", "voor"}, []string{"een", "ver", "ijk", "aar", "oor", "sch", "ijn"}},
+}
+
+// The minimum score, and lead over the runner-up, for a guess in the Latin
+// script to be trusted. Short queries rarely have enough evidence, and are
+// then left to the default wiki.
+const latinMinimumScore = 3
+const latinMinimumLead = 2
+
+// DetectLanguage guesses the Wikipedia language of a query from the
+// Unicode scripts it is written in and, for the Latin script, from simple
+// letter and word statistics. It is a fully offline heuristic.
+//
+// The reason is a short human readable explanation of the guess. If no
+// language stands out, or the guess is English, detected is false.
+func DetectLanguage(text string) (lang string, reason string, detected bool) {
+	counts := map[string]int{}
+	letters := 0
+	kana, han, latin := 0, 0, 0
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		switch {
+		case unicode.In(r, unicode.Hiragana, unicode.Katakana):
+			kana++
+		case unicode.Is(unicode.Han, r):
+			han++
+		case unicode.Is(unicode.Latin, r):
+			latin++
+		default:
+			for _, script := range scriptLanguages {
+				if unicode.Is(script.script, r) {
+					counts[script.lang]++
+					break
+				}
+			}
+		}
+	}
+	if letters == 0 {
+		return "", "", false
+	}
+
+	// Any kana means Japanese, even when mixed with kanji
+	if kana > 0 && (kana+han)*2 > letters {
+		return "ja", "the query is written in Japanese kana", true
+	}
+	if han*2 > letters {
+		return "zh", "the query is written in Chinese characters", true
+	}
+
+	for _, script := range scriptLanguages {
+		if counts[script.lang]*2 <= letters {
+			continue
+		}
+		for _, variant := range scriptVariants[script.lang] {
+			if strings.ContainsAny(text, variant.letters) {
+				return variant.lang, "the query is written in " + script.name + " script with " + variant.name + " letters", true
+			}
+		}
+		return script.lang, "the query is written in " + script.name + " script", true
+	}
+
+	if latin*2 > letters {
+		return detectLatinLanguage(text)
+	}
+	return "", "", false
+}
+
+// Guess the language of a query in the Latin script by scoring each
+// language profile against it
+func detectLatinLanguage(text string) (lang string, reason string, detected bool) {
+	lower := strings.ToLower(text)
+	words := strings.FieldsFunc(lower, func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+
+	type score struct {
+		profile  latinProfile
+		points   int
+		evidence []string
+	}
+	scores := []score{}
+	for _, profile := range latinProfiles {
+		current := score{profile: profile}
+		for _, letter := range profile.letters {
+			if strings.ContainsRune(lower, letter) {
+				current.points += 2
+				current.evidence = append(current.evidence, string(letter))
+			}
+		}
+		for _, word := range words {
+			for _, common := range profile.words {
+				if word == common {
+					current.points++
+					current.evidence = append(current.evidence, word)
+				}
+			}
+			for _, trigram := range profile.trigrams {
+				if len(word) > len(trigram) && strings.Contains(word, trigram) {
+					current.points++
+				}
+			}
+		}
+		scores = append(scores, current)
+	}
+	sort.SliceStable(scores, func(i, j int) bool {
+		return scores[i].points > scores[j].points
+	})
+
+	best := scores[0]
+	if best.points < latinMinimumScore || best.points-scores[1].points < latinMinimumLead || best.profile.lang == "en" {
+		return "", "", false
+	}
+	reason = "the query looks like " + best.profile.name
+	if len(best.evidence) > 0 {
+		reason += " (" + strings.Join(best.evidence, ", ") + ")"
+	}
+	return best.profile.lang, reason, true
+}
@@ -0,0 +1,36 @@
+package wikipedia
+
+import (
+	"testing"
+)
+
+func Test_DetectLanguage(t *testing.T) {
+	tests := []struct {
+		name             string
+		text             string
+		expectedLang     string
+		expectedDetected bool
+	}{
+		{"English", "summer vacation", "", false},
+		{"Numbers only", "2020", "", false},
+		{"Hebrew", "תל אביב", "he", true},
+		{"Japanese kana and kanji", "東京タワー", "ja", true},
+		{"Chinese", "北京大学", "zh", true},
+		{"Russian", "Москва", "ru", true},
+		{"Ukrainian", "Київ", "uk", true},
+		{"Persian", "پاریس", "fa", true},
+		{"Arabic", "القاهرة", "ar", true},
+		{"Korean", "서울", "ko", true},
+		{"German", "Straße der Einheit", "de", true},
+		{"Spanish", "el niño y la niña", "es", true},
+		{"Short ambiguous Latin", "Paris", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lang, reason, detected := DetectLanguage(tt.text)
+			if lang != tt.expectedLang || detected != tt.expectedDetected {
+				t.Errorf("DetectLanguage(%q) = %v, %v (%s), want %v, %v", tt.text, lang, detected, reason, tt.expectedLang, tt.expectedDetected)
+			}
+		})
+	}
+}
@@ -0,0 +1,232 @@
+package wikipedia
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var wikiAnalyticsTopEditedPagesEndpoint = "https://wikimedia.org/api/rest_v1/metrics/edited-pages/top-by-edits/%s/all-editor-types/content/%d/%02d/%s" // "2020/06/02" or "2020/06/all-days"
+var wikiAnalyticsTopEditorsEndpoint = "https://wikimedia.org/api/rest_v1/metrics/editors/top-by-edits/%s/user/content/%d/%02d/%s"                      // "2020/06/02" or "2020/06/all-days"
+var wikiAnalyticsEditsEndpoint = "https://wikimedia.org/api/rest_v1/metrics/edits/aggregate/%s/%s/content/daily/%s/%s"                                 // editor type/start/end
+
+// The number of most active editors in an edit activity summary
+const topEditorsLimit = 3
+
+// EditActivity is the editing of a wiki's articles over a range of days
+type EditActivity struct {
+	Range DateRange
+	// Pages are the most edited articles, with their edits in Info
+	Pages []PagelistPage
+	// Editors are the registered users who edited articles the most,
+	// without bots, with their edits in Info
+	Editors []PagelistPage
+	// Edits is the number of edits to articles
+	Edits int
+	// AnonymousEdits and BotEdits are the parts of the edits that were
+	// made by people who weren't logged in, and by bots
+	AnonymousEdits int
+	BotEdits       int
+	// HasEdits, HasAnonymousEdits and HasBotEdits are set if the matching
+	// count could be fetched
+	HasEdits          bool
+	HasAnonymousEdits bool
+	HasBotEdits       bool
+}
+
+// FetchTopEdits fetches the most edited articles and the edit activity for
+// a given date or range, like "March 2020", read like in ParseDateOrRange,
+// or for the latest month with edit statistics if the string is empty.
+// Dates that can't be read are not found. Lang parameter will dictate the
+// Wikipedia that will be searched. If given empty string, will fall back on "en"
+func FetchTopEdits(datestring string, lang string) (activity EditActivity, found bool) {
+	dateRange, _, err := ParseDateOrRange(datestring, SystemClock.Now())
+	if err != nil {
+		toLog("FetchTopEdits", "Failed to parse given date string: "+datestring)
+		return activity, false
+	}
+	if len(strings.TrimSpace(datestring)) == 0 {
+		dateRange = EditsAvailability.LatestMonth()
+	}
+	return Wikipedia(lang).EditActivity(dateRange, 10)
+}
+
+// EditActivity fetches the most edited articles of a range of days, up to
+// the limit, with a summary of the edits and the most active editors, from
+// the same analytics project as the pageviews. The lists and the summary
+// are fetched at the same time. Whole calendar months
+// are fetched as a month, and the other days one by one. Ranges longer
+// than TopRangeMaxDays are cut down to their latest days. Found is false
+// if there are no edits for the range; the analytics are only updated once
+// a month, see EditsAvailability.
+func (w *MediaWiki) EditActivity(dateRange DateRange, limit int) (activity EditActivity, found bool) {
+	if dateRange.Days() > TopRangeMaxDays {
+		toLog("EditActivity", "Range cut down to the last "+strconv.Itoa(TopRangeMaxDays)+" days: "+dateRange.String())
+		dateRange.Start = dateRange.End.AddDate(0, 0, 1-TopRangeMaxDays)
+	}
+	activity.Range = dateRange
+	if !w.Supports(FeatureEdits) {
+		return activity, false
+	}
+
+	var wait sync.WaitGroup
+	var pages, editors map[string]int
+	wait.Add(5)
+	go func() {
+		defer wait.Done()
+		pages = w.topByEditsTotals(dateRange, wikiAnalyticsTopEditedPagesEndpoint)
+	}()
+	go func() {
+		defer wait.Done()
+		editors = w.topByEditsTotals(dateRange, wikiAnalyticsTopEditorsEndpoint)
+	}()
+	for _, count := range []struct {
+		editorType string
+		edits      *int
+		found      *bool
+	}{
+		{"all-editor-types", &activity.Edits, &activity.HasEdits},
+		{"anonymous", &activity.AnonymousEdits, &activity.HasAnonymousEdits},
+		{"group-bot", &activity.BotEdits, &activity.HasBotEdits},
+	} {
+		go func(editorType string, edits *int, found *bool) {
+			defer wait.Done()
+			*edits, *found = w.editsCount(dateRange, editorType)
+		}(count.editorType, count.edits, count.found)
+	}
+	wait.Wait()
+
+	if len(pages) == 0 {
+		return activity, false
+	}
+	activity.Pages = rankTopPageviews(pages, w)
+	if len(activity.Pages) > limit {
+		activity.Pages = activity.Pages[:limit]
+	}
+	activity.Editors = rankEditors(editors, w, topEditorsLimit)
+	return activity, true
+}
+
+// Fetch the edits of the top pages or editors of each whole month and
+// single day in the range at the same time, and add them up
+func (w *MediaWiki) topByEditsTotals(dateRange DateRange, endpoint string) map[string]int {
+	segments := rangeSegments(dateRange)
+	answers := make(chan map[string]int, len(segments))
+	slots := make(chan bool, topPageviewsConcurrency)
+	for _, segment := range segments {
+		go func(segment DateRange) {
+			slots <- true
+			defer func() { <-slots }()
+			t := segment.Start
+			day := fmt.Sprintf("%02d", t.Day())
+			if segment.IsWholeMonth() {
+				day = "all-days"
+			}
+			url := fmt.Sprintf(endpoint, w.PageviewsProject, t.Year(), int(t.Month()), day)
+			if cached := cachedTopTotals(url); cached != nil {
+				answers <- cached
+				return
+			}
+			toLog("topByEditsTotals", "URL: "+url)
+
+			body, readErr := fetchFromAPI(url)
+			if readErr != nil {
+				answers <- nil
+				return
+			}
+			segmentTotals := processAnalyticsTopByEdits(body)
+			cacheTopTotals(url, segmentTotals)
+			answers <- segmentTotals
+		}(segment)
+	}
+
+	totals := map[string]int{}
+	for range segments {
+		for name, edits := range <-answers {
+			totals[name] += edits
+		}
+	}
+	return totals
+}
+
+// Fetch the number of edits to articles made by the given type of editors.
+// Found is false if the number couldn't be fetched.
+func (w *MediaWiki) editsCount(dateRange DateRange, editorType string) (edits int, found bool) {
+	// The end of the range is the first day that isn't counted
+	url := fmt.Sprintf(wikiAnalyticsEditsEndpoint, w.PageviewsProject, editorType,
+		dateRange.Start.Format("20060102"), dateRange.End.AddDate(0, 0, 1).Format("20060102"))
+	toLog("editsCount", "URL: "+url)
+
+	body, readErr := fetchFromAPI(url)
+	if readErr != nil {
+		return 0, false
+	}
+	return processAnalyticsEditsCount(body)
+}
+
+// Process the result from the Wikipedia analytics top-by-edits endpoints
+// and return the edits of each page or editor in it, or nil if there is
+// no list
+func processAnalyticsTopByEdits(body []byte) map[string]int {
+	record := AnalyticsTopByEdits{}
+	jsonErr := json.Unmarshal(body, &record)
+	if jsonErr != nil || len(record.Items) == 0 {
+		if len(record.Detail) != 0 {
+			toLog("processAnalyticsTopByEdits", "Error fetching. Details: "+record.Detail)
+		}
+		return nil
+	}
+	totals := map[string]int{}
+	for _, result := range record.Items[0].Results {
+		for _, top := range result.Top {
+			name := top.PageTitle
+			if len(name) == 0 {
+				name = top.UserText
+			}
+			totals[name] += top.Edits
+		}
+	}
+	return totals
+}
+
+// Process the result from the Wikipedia analytics edits endpoint and
+// return the number of edits in it. Found is false if there are no counts
+// in it.
+func processAnalyticsEditsCount(body []byte) (edits int, found bool) {
+	record := AnalyticsEditsAggregate{}
+	if jsonErr := json.Unmarshal(body, &record); jsonErr != nil || len(record.Items) == 0 {
+		if len(record.Detail) != 0 {
+			toLog("processAnalyticsEditsCount", "Error fetching. Details: "+record.Detail)
+		}
+		return 0, false
+	}
+	for _, result := range record.Items[0].Results {
+		edits += result.Edits
+	}
+	return edits, true
+}
+
+// Output the editors with the most edits, linked to their user pages
+func rankEditors(totals map[string]int, wiki *MediaWiki, limit int) (list []PagelistPage) {
+	names := []string{}
+	for name := range totals {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return totals[names[i]] > totals[names[j]] || (totals[names[i]] == totals[names[j]] && names[i] < names[j])
+	})
+	for index, name := range names {
+		if index == limit {
+			break
+		}
+		list = append(list, PagelistPage{
+			strings.ReplaceAll(name, "_", " "), // Title
+			wiki.ArticleURL("User:" + name),    // URL
+			index + 1,                          // Rank
+			strconv.Itoa(totals[name])})        // Edits, stringified
+	}
+	return list
+}
@@ -0,0 +1,59 @@
+package wikipedia
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_processAnalyticsTopByEdits(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		expected map[string]int
+	}{
+		{
+			"Pages",
+			`{"items":[{"project":"en.wikipedia","editor-type":"all-editor-types","page-type":"content","granularity":"daily","results":[{"timestamp":"2020-03-01T00:00:00.000Z","top":[{"page_title":"Coronavirus_disease_2019","edits":412,"rank":1},{"page_title":"Tokyo","edits":35,"rank":2}]}]}]}`,
+			map[string]int{"Coronavirus_disease_2019": 412, "Tokyo": 35},
+		},
+		{
+			"Editors",
+			`{"items":[{"project":"en.wikipedia","editor-type":"user","page-type":"content","granularity":"monthly","results":[{"timestamp":"2020-03-01T00:00:00.000Z","top":[{"user_text":"Example","edits":950,"rank":1}]}]}]}`,
+			map[string]int{"Example": 950},
+		},
+		{
+			"Not loaded yet",
+			`{"type":"https://mediawiki.org/wiki/HyperSwitch/errors/not_found","title":"Not found.","detail":"The date(s) you used are valid, but we either do not have data for those date(s), or the project you asked for is not loaded yet."}`,
+			nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if totals := processAnalyticsTopByEdits([]byte(tt.body)); !reflect.DeepEqual(totals, tt.expected) {
+				t.Errorf("processAnalyticsTopByEdits() = %v, want %v", totals, tt.expected)
+			}
+		})
+	}
+}
+
+func Test_processAnalyticsEditsCount(t *testing.T) {
+	body := []byte(`{"items":[{"project":"en.wikipedia","editor-type":"all-editor-types","page-type":"content","granularity":"daily","results":[{"timestamp":"2020-03-01T00:00:00.000Z","edits":120000},{"timestamp":"2020-03-02T00:00:00.000Z","edits":130500}]}]}`)
+	if edits, found := processAnalyticsEditsCount(body); edits != 250500 || !found {
+		t.Errorf("processAnalyticsEditsCount() = %d, %v, want 250500, true", edits, found)
+	}
+	notFound := []byte(`{"type":"https://mediawiki.org/wiki/HyperSwitch/errors/not_found","title":"Not found.","detail":"The date(s) you used are valid, but we either do not have data for those date(s), or the project you asked for is not loaded yet."}`)
+	if edits, found := processAnalyticsEditsCount(notFound); edits != 0 || found {
+		t.Errorf("processAnalyticsEditsCount() = %d, %v, want 0, false", edits, found)
+	}
+}
+
+func Test_rankEditors(t *testing.T) {
+	totals := map[string]int{"Alpha": 10, "Beta_Bot_Owner": 30, "Gamma": 20, "Delta": 5}
+	expected := []PagelistPage{
+		{"Beta Bot Owner", "https://en.wikipedia.org/wiki/User:Beta_Bot_Owner", 1, "30"},
+		{"Gamma", "https://en.wikipedia.org/wiki/User:Gamma", 2, "20"},
+	}
+	if list := rankEditors(totals, Wikipedia("en"), 2); !reflect.DeepEqual(list, expected) {
+		t.Errorf("rankEditors() = %v, want %v", list, expected)
+	}
+}
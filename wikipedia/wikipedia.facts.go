@@ -0,0 +1,234 @@
+package wikipedia
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// PropertyAliases maps the ways people ask for a fact to the
+// Wikidata property that holds it
+var PropertyAliases = map[string]string{
+	"population":         "P1082",
+	"inhabitants":        "P1082",
+	"birth date":         "P569",
+	"date of birth":      "P569",
+	"birthday":           "P569",
+	"born":               "P569",
+	"birth place":        "P19",
+	"birthplace":         "P19",
+	"place of birth":     "P19",
+	"death date":         "P570",
+	"date of death":      "P570",
+	"died":               "P570",
+	"death place":        "P20",
+	"place of death":     "P20",
+	"capital":            "P36",
+	"area":               "P2046",
+	"size":               "P2046",
+	"inception":          "P571",
+	"founded":            "P571",
+	"founding date":      "P571",
+	"founder":            "P112",
+	"founders":           "P112",
+	"height":             "P2048",
+	"elevation":          "P2044",
+	"altitude":           "P2044",
+	"mass":               "P2067",
+	"weight":             "P2067",
+	"length":             "P2043",
+	"currency":           "P38",
+	"official language":  "P37",
+	"language":           "P37",
+	"head of government": "P6",
+	"mayor":              "P6",
+	"head of state":      "P35",
+	"president":          "P35",
+	"ceo":                "P169",
+	"chief executive":    "P169",
+	"headquarters":       "P159",
+	"employees":          "P1128",
+	"website":            "P856",
+	"official website":   "P856",
+	"country":            "P17",
+	"continent":          "P30",
+	"time zone":          "P421",
+	"timezone":           "P421",
+	"coordinates":        "P625",
+	"location":           "P625",
+	"spouse":             "P26",
+	"children":           "P40",
+	"occupation":         "P106",
+	"job":                "P106",
+	"citizenship":        "P27",
+	"nationality":        "P27",
+	"author":             "P50",
+	"director":           "P57",
+	"publication date":   "P577",
+	"release date":       "P577",
+	"released":           "P577",
+	"gdp":                "P2131",
+	"genre":              "P136",
+	"named after":        "P138",
+	"architect":          "P84",
+	"owner":              "P127",
+	"parent company":     "P749",
+}
+
+// Qualifiers are the details of a value, like the date a population
+// was counted. Only these qualifiers are shown, in this order.
+var factQualifiers = []string{"P585", "P580", "P582", "P459", "P642", "P518"}
+
+// FactValue is a single value of a property, with its qualifiers and source
+type FactValue struct {
+	Value string
+	// Qualifiers are formatted as "label: value"
+	Qualifiers  []string
+	SourceURL   string
+	SourceLabel string
+}
+
+// FactAnswer is the answer to a question about a single property of
+// the Wikidata item of an article
+type FactAnswer struct {
+	Page          Page
+	Item          string
+	Label         string
+	Property      string
+	PropertyLabel string
+	Values        []FactValue
+	// URL is the link to the statement on Wikidata
+	URL string
+}
+
+// Raw Wikidata property IDs in a question, like "P1082"
+var propertyIDRegexp = regexp.MustCompile(`(?i)(^|\s)(P[0-9]+)($|\s)`)
+
+// ParseFactQuestion splits a question like "population of Tokyo",
+// "Tokyo population" or "Tokyo P1082" into the entity and the Wikidata
+// property ID, using PropertyAliases. Found is false if no property
+// was recognized.
+func ParseFactQuestion(text string) (entity string, property string, found bool) {
+	text = strings.TrimSpace(text)
+	lower := strings.ToLower(text)
+
+	// Raw property IDs
+	if match := propertyIDRegexp.FindStringSubmatchIndex(text); match != nil {
+		property = strings.ToUpper(text[match[4]:match[5]])
+		entity = strings.TrimSpace(text[:match[0]] + " " + text[match[1]:])
+		return entity, property, len(entity) != 0
+	}
+
+	// "<property> of <entity>"; the property may have an "of" itself
+	for index := strings.Index(lower, " of "); index > 0; {
+		if property, ok := PropertyAliases[strings.TrimSpace(lower[:index])]; ok {
+			return strings.TrimSpace(text[index+4:]), property, true
+		}
+		next := strings.Index(lower[index+1:], " of ")
+		if next < 0 {
+			break
+		}
+		index += next + 1
+	}
+
+	// "<entity> <property>" or "<property> <entity>", preferring the longest alias
+	aliases := []string{}
+	for alias := range PropertyAliases {
+		aliases = append(aliases, alias)
+	}
+	sort.Slice(aliases, func(i, j int) bool {
+		return len(aliases[i]) > len(aliases[j]) || (len(aliases[i]) == len(aliases[j]) && aliases[i] < aliases[j])
+	})
+	for _, alias := range aliases {
+		switch {
+		case strings.HasSuffix(lower, " "+alias):
+			return strings.TrimSpace(text[:len(text)-len(alias)]), PropertyAliases[alias], true
+		case strings.HasPrefix(lower, alias+" "):
+			return strings.TrimSpace(text[len(alias):]), PropertyAliases[alias], true
+		}
+	}
+	return text, "", false
+}
+
+// FetchFact answers a question about a single property of an article,
+// like "population of Tokyo". The article is found the same way
+// FetchGetGeneralTerm does, and the fact is read from its Wikidata item.
+func FetchFact(question string) (answer FactAnswer, wiki Backend, found bool) {
+	wiki, question = ParseWikiFromText(question)
+	entity, property, ok := ParseFactQuestion(question)
+	answer.Property = property
+	if !ok || len(entity) == 0 {
+		return answer, wiki, false
+	}
+
+	results, _ := getGeneralTerm(wiki, entity, false)
+	if len(results) != 1 || results[0].Title == "Not found." || len(results[0].WikibaseItem) == 0 {
+		return answer, wiki, false
+	}
+	answer.Page = results[0]
+	answer.Item = results[0].WikibaseItem
+	answer.URL = fmt.Sprintf(wikidataEntityPath, answer.Item) + "#" + property
+
+	lang := wiki.Language()
+	entities := fetchWikidataEntities([]string{answer.Item}, "labels|claims", lang)
+	item, ok := entities[answer.Item]
+	if !ok || item.Missing != nil {
+		return answer, wiki, false
+	}
+	answer.Label = localizedText(item.Labels, lang)
+
+	// Fetch the labels of everything the answer mentions at once
+	claims := bestClaims(item.Claims[property])
+	ids := []string{property}
+	for _, claim := range claims {
+		ids = append(ids, snakReferencedIDs(claim.Mainsnak)...)
+		for _, qualifier := range factQualifiers {
+			for _, snak := range claim.Qualifiers[qualifier] {
+				ids = append(ids, snakReferencedIDs(snak)...)
+			}
+		}
+		for _, reference := range claim.References {
+			for _, snak := range reference.Snaks["P248"] {
+				ids = append(ids, snakReferencedIDs(snak)...)
+			}
+		}
+	}
+	labels := fetchWikidataLabels(ids, lang)
+	answer.PropertyLabel = labelOf(property, labels)
+
+	for _, claim := range claims {
+		value := formatSnakValue(claim.Mainsnak, labels)
+		if len(value) == 0 {
+			continue
+		}
+		answer.Values = append(answer.Values, formatFactValue(claim, value, labels))
+	}
+	return answer, wiki, len(answer.Values) > 0
+}
+
+// Add the qualifiers and the source of a claim to its formatted value
+func formatFactValue(claim WikidataClaim, value string, labels map[string]string) FactValue {
+	factValue := FactValue{Value: value}
+	for _, qualifier := range factQualifiers {
+		for _, snak := range claim.Qualifiers[qualifier] {
+			if formatted := formatSnakValue(snak, labels); len(formatted) != 0 {
+				factValue.Qualifiers = append(factValue.Qualifiers, labelOf(qualifier, labels)+": "+formatted)
+			}
+		}
+	}
+
+	// Use the first reference that says where the value comes from
+	for _, reference := range claim.References {
+		for _, snak := range reference.Snaks["P854"] {
+			factValue.SourceURL = formatSnakValue(snak, labels)
+		}
+		for _, snak := range reference.Snaks["P248"] {
+			factValue.SourceLabel = formatSnakValue(snak, labels)
+		}
+		if len(factValue.SourceURL) != 0 || len(factValue.SourceLabel) != 0 {
+			break
+		}
+	}
+	return factValue
+}
@@ -0,0 +1,50 @@
+package wikipedia
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_ParseFactQuestion(t *testing.T) {
+	tests := []struct {
+		name             string
+		text             string
+		expectedEntity   string
+		expectedProperty string
+		expectedFound    bool
+	}{
+		{"Property of entity", "population of Tokyo", "Tokyo", "P1082", true},
+		{"Property with of", "date of birth of Ada Lovelace", "Ada Lovelace", "P569", true},
+		{"Entity then property", "Ada Lovelace birth date", "Ada Lovelace", "P569", true},
+		{"Property then entity", "capital France", "France", "P36", true},
+		{"Entity with of", "Bank of England founder", "Bank of England", "P112", true},
+		{"Raw property ID", "Tokyo p2046", "Tokyo", "P2046", true},
+		{"Unknown property", "Tokyo smell", "Tokyo smell", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entity, property, found := ParseFactQuestion(tt.text)
+			if entity != tt.expectedEntity || property != tt.expectedProperty || found != tt.expectedFound {
+				t.Errorf("ParseFactQuestion() = %q, %q, %v, want %q, %q, %v", entity, property, found, tt.expectedEntity, tt.expectedProperty, tt.expectedFound)
+			}
+		})
+	}
+}
+
+func Test_formatFactValue(t *testing.T) {
+	entities := processWikidataEntities([]byte(`{"entities":{"Q1490":{"id":"Q1490","claims":{"P1082":[{"mainsnak":{"snaktype":"value","property":"P1082","datavalue":{"value":{"amount":"+13960000","unit":"1"},"type":"quantity"}},"rank":"preferred",
+		"qualifiers":{"P585":[{"snaktype":"value","property":"P585","datavalue":{"value":{"time":"+2019-10-01T00:00:00Z","precision":11},"type":"time"}}]},
+		"references":[{"snaks":{"P143":[{"snaktype":"value","property":"P143","datavalue":{"value":{"id":"Q328"},"type":"wikibase-entityid"}}]}},{"snaks":{"P854":[{"snaktype":"value","property":"P854","datavalue":{"value":"https://www.toukei.metro.tokyo.lg.jp/","type":"string"}}],"P248":[{"snaktype":"value","property":"P248","datavalue":{"value":{"id":"Q11469566"},"type":"wikibase-entityid"}}]}}]}]}}}}`))
+	claim := entities["Q1490"].Claims["P1082"][0]
+	labels := map[string]string{"P585": "point in time", "Q11469566": "Tokyo statistics"}
+
+	expected := FactValue{
+		Value:       "13,960,000",
+		Qualifiers:  []string{"point in time: 1 October 2019"},
+		SourceURL:   "https://www.toukei.metro.tokyo.lg.jp/",
+		SourceLabel: "Tokyo statistics",
+	}
+	if value := formatFactValue(claim, formatSnakValue(claim.Mainsnak, labels), labels); !reflect.DeepEqual(value, expected) {
+		t.Errorf("formatFactValue() = %v\nExpected:\n %v", value, expected)
+	}
+}
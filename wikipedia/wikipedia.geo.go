@@ -0,0 +1,193 @@
+package wikipedia
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// The largest radius, in meters, the geosearch API accepts
+const nearbyMaxRadius = 10000
+
+// The radius used when none is given
+const nearbyDefaultRadius = 1000
+
+const nearbyLimit = 10
+
+var openStreetMapURL = "https://www.openstreetmap.org/?mlat=%.5f&mlon=%.5f#map=%d/%.5f/%.5f"
+
+// NearbyPage is an article close to a location
+type NearbyPage struct {
+	Title       string
+	URL         string
+	Coordinates Coordinates
+	// Distance is in meters
+	Distance float64
+}
+
+// OpenStreetMapURL is the link to the coordinates on OpenStreetMap
+func (c Coordinates) OpenStreetMapURL() string {
+	return fmt.Sprintf(openStreetMapURL, c.Lat, c.Lon, 13, c.Lat, c.Lon)
+}
+
+// String formats the coordinates as "35.68950, 139.69170"
+func (c Coordinates) String() string {
+	return fmt.Sprintf("%.5f, %.5f", c.Lat, c.Lon)
+}
+
+// FetchNearby lists the articles near a place, closest first. The text
+// is a place name or "lat,lon", optionally followed by a radius like
+// "500m" or "2km". The place is found the same way FetchGetGeneralTerm
+// does, and must have coordinates. Center is nil if the location could
+// not be worked out.
+func FetchNearby(text string) (results []NearbyPage, center *Coordinates, place Page, radius int, wiki Backend) {
+	wiki, text = ParseWikiFromText(text)
+	location, radius := ParseNearbyText(text)
+	results = []NearbyPage{}
+
+	mediaWiki, ok := wiki.(*MediaWiki)
+	if !ok || len(location) == 0 {
+		return results, nil, place, radius, wiki
+	}
+
+	center = parseCoordinates(location)
+	if center == nil {
+		pages, _ := getGeneralTerm(wiki, location, false)
+		if len(pages) != 1 || pages[0].Coordinates == nil {
+			return results, nil, place, radius, wiki
+		}
+		place = pages[0]
+		center = place.Coordinates
+	}
+
+	results = mediaWiki.Nearby(*center, radius)
+	// The place itself is the closest result
+	filtered := results[:0]
+	for _, result := range results {
+		if result.Title != place.Title {
+			filtered = append(filtered, result)
+		}
+	}
+	if len(filtered) > nearbyLimit {
+		filtered = filtered[:nearbyLimit]
+	}
+	return filtered, center, place, radius, wiki
+}
+
+// A radius at the end of a nearby request, like "500m" or "2 km". The unit
+// is required, so titles like "Area 51" keep their number.
+var radiusRegexp = regexp.MustCompile(`(?i)\s+([0-9]+(?:\.[0-9]+)?)\s*(km|m)$`)
+
+// Coordinates like "48.8584,2.2945" or "48.8584 2.2945"
+var coordinatesRegexp = regexp.MustCompile(`^(-?[0-9]+(?:\.[0-9]+)?)\s*[,\s]\s*(-?[0-9]+(?:\.[0-9]+)?)$`)
+
+// ParseNearbyText splits a trailing radius, like "500m" or "2km", from the
+// location. The radius is clamped to what the API allows.
+func ParseNearbyText(text string) (location string, radius int) {
+	location = strings.TrimSpace(text)
+	radius = nearbyDefaultRadius
+
+	match := radiusRegexp.FindStringSubmatchIndex(location)
+	// A bare "lat lon" pair has no radius
+	if match == nil || parseCoordinates(location) != nil {
+		return location, radius
+	}
+
+	value, _ := strconv.ParseFloat(location[match[2]:match[3]], 64)
+	if strings.ToLower(location[match[4]:match[5]]) == "km" {
+		value *= 1000
+	}
+	radius = int(value)
+	if radius < 10 {
+		radius = 10
+	}
+	if radius > nearbyMaxRadius {
+		radius = nearbyMaxRadius
+	}
+	return strings.TrimSpace(location[:match[0]]), radius
+}
+
+// Parse "lat,lon" into coordinates, or nil if the text is not a valid pair
+func parseCoordinates(text string) *Coordinates {
+	match := coordinatesRegexp.FindStringSubmatch(strings.TrimSpace(text))
+	if match == nil {
+		return nil
+	}
+	lat, _ := strconv.ParseFloat(match[1], 64)
+	lon, _ := strconv.ParseFloat(match[2], 64)
+	if lat < -90 || lat > 90 || lon < -180 || lon > 180 {
+		return nil
+	}
+	return &Coordinates{lat, lon}
+}
+
+// Nearby lists the articles within the radius, in meters, of the
+// coordinates, closest first
+func (w *MediaWiki) Nearby(center Coordinates, radius int) []NearbyPage {
+	params := url.Values{}
+
+	params.Add("action", "query")
+	params.Add("format", "json")
+	params.Add("list", "geosearch")
+	params.Add("gscoord", fmt.Sprintf("%f|%f", center.Lat, center.Lon))
+	params.Add("gsradius", strconv.Itoa(radius))
+	params.Add("gslimit", strconv.Itoa(nearbyLimit+1))
+	params.Add("gsnamespace", "0")
+
+	url := w.actionAPIURL(params)
+	toLog("Nearby", "URL: "+url)
+
+	body, readErr := fetchFromAPI(url)
+	if readErr != nil {
+		return []NearbyPage{}
+	}
+	return processGeosearch(body, w)
+}
+
+// Output the nearby pages of a geosearch response, closest first
+func processGeosearch(body []byte, wiki *MediaWiki) (pages []NearbyPage) {
+	record := ActionAPIGeosearchResponse{}
+	pages = []NearbyPage{}
+	if jsonErr := json.Unmarshal(body, &record); jsonErr != nil {
+		return pages
+	}
+	if len(record.Error.Code) != 0 {
+		toLog("processGeosearch", "API error: "+record.Error.Info)
+		return pages
+	}
+
+	for _, result := range record.Query.Geosearch {
+		pages = append(pages, NearbyPage{
+			Title:       result.Title,
+			URL:         wiki.ArticleURL(result.Title),
+			Coordinates: Coordinates{result.Lat, result.Lon},
+			Distance:    result.Dist,
+		})
+	}
+	sort.SliceStable(pages, func(i, j int) bool {
+		return pages[i].Distance < pages[j].Distance
+	})
+	return pages
+}
+
+// Output the primary location of an action API page, if it has one on Earth
+func primaryCoordinates(coordinates []ActionAPICoordinates) *Coordinates {
+	for _, location := range coordinates {
+		if location.Primary != nil && (len(location.Globe) == 0 || location.Globe == "earth") {
+			return &Coordinates{location.Lat, location.Lon}
+		}
+	}
+	return nil
+}
+
+// Output the location of a REST summary, if it has one
+func restCoordinates(record PageResponseREST) *Coordinates {
+	if record.Coordinates == nil {
+		return nil
+	}
+	return &Coordinates{record.Coordinates.Lat, record.Coordinates.Lon}
+}
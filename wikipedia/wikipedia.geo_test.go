@@ -0,0 +1,70 @@
+package wikipedia
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_ParseNearbyText(t *testing.T) {
+	tests := []struct {
+		name             string
+		text             string
+		expectedLocation string
+		expectedRadius   int
+	}{
+		{"Place only", "Eiffel Tower", "Eiffel Tower", nearbyDefaultRadius},
+		{"Radius in meters", "Eiffel Tower 500m", "Eiffel Tower", 500},
+		{"Number without unit", "Eiffel Tower 800", "Eiffel Tower 800", nearbyDefaultRadius},
+		{"Title ending in a number", "Area 51", "Area 51", nearbyDefaultRadius},
+		{"Title ending in a number and radius", "Apollo 11 2km", "Apollo 11", 2000},
+		{"Radius in kilometers", "Eiffel Tower 2.5 km", "Eiffel Tower", 2500},
+		{"Radius over the limit", "Eiffel Tower 50km", "Eiffel Tower", nearbyMaxRadius},
+		{"Coordinates only", "48.8584, 2.2945", "48.8584, 2.2945", nearbyDefaultRadius},
+		{"Coordinates with a space", "48.8584 2.2945", "48.8584 2.2945", nearbyDefaultRadius},
+		{"Coordinates and radius", "48.8584,2.2945 3km", "48.8584,2.2945", 3000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			location, radius := ParseNearbyText(tt.text)
+			if location != tt.expectedLocation || radius != tt.expectedRadius {
+				t.Errorf("ParseNearbyText() = %q, %d, want %q, %d", location, radius, tt.expectedLocation, tt.expectedRadius)
+			}
+		})
+	}
+}
+
+func Test_parseCoordinates(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		expected *Coordinates
+	}{
+		{"Comma separated", "48.8584,2.2945", &Coordinates{48.8584, 2.2945}},
+		{"Negative values", "-33.8568, -70.6483", &Coordinates{-33.8568, -70.6483}},
+		{"Out of range", "98.1, 2.2", nil},
+		{"Place name", "Paris", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if coordinates := parseCoordinates(tt.text); !reflect.DeepEqual(coordinates, tt.expected) {
+				t.Errorf("parseCoordinates() = %v, want %v", coordinates, tt.expected)
+			}
+		})
+	}
+}
+
+func Test_processGeosearch(t *testing.T) {
+	body := []byte(`{"batchcomplete":"","query":{"geosearch":[{"pageid":2,"ns":0,"title":"Champ de Mars","lat":48.8556,"lon":2.2986,"dist":412.3,"primary":""},{"pageid":1,"ns":0,"title":"Eiffel Tower","lat":48.8583,"lon":2.2944,"dist":0,"primary":""}]}}`)
+	expected := []NearbyPage{
+		{"Eiffel Tower", "https://en.wikipedia.org/wiki/Eiffel_Tower", Coordinates{48.8583, 2.2944}, 0},
+		{"Champ de Mars", "https://en.wikipedia.org/wiki/Champ_de_Mars", Coordinates{48.8556, 2.2986}, 412.3},
+	}
+	if pages := processGeosearch(body, Wikipedia("en")); !reflect.DeepEqual(pages, expected) {
+		t.Errorf("processGeosearch() = %v\nExpected:\n %v", pages, expected)
+	}
+
+	errorBody := []byte(`{"error":{"code":"badcoord","info":"Invalid coordinate provided"}}`)
+	if pages := processGeosearch(errorBody, Wikipedia("en")); len(pages) != 0 {
+		t.Errorf("processGeosearch() with an error = %v, want no pages", pages)
+	}
+}
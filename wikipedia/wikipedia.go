@@ -1,11 +1,10 @@
 package wikipedia
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
-
-	"github.com/araddon/dateparse"
 	"net/http"
 	"net/url"
 	"regexp"
@@ -15,13 +14,13 @@ import (
 	"time"
 )
 
-// TODO: Maybe make this configurable? Allow for other languages?
+// Wikipedia defaults; other wikis are configured through the MediaWiki backend
 var wikiBaseArticlePath = "https://%s.wikipedia.org/wiki/%s"
 var wikiRESTEndpoint = "https://%s.wikipedia.org/api/rest_v1/"
 var wikiRESTsummary = "page/summary/%s?redirect=true"
 var wikiRESTrelated = "page/related/%s"
 var wikiActionAPIendpoint = "https://%s.wikipedia.org/w/api.php"
-var wikiAnalyticsPageviewsEndpoint = "https://wikimedia.org/api/rest_v1/metrics/pageviews/top/%s.wikipedia/all-access/%d/%02d/%02d" // "2020/06/02"
+var wikiAnalyticsPageviewsEndpoint = "https://wikimedia.org/api/rest_v1/metrics/pageviews/top/%s/%s/%d/%02d/%02d" // "all-access/2020/06/02"
 
 // Page is a normalized structure for representing page data
 type Page struct {
@@ -30,6 +29,22 @@ type Page struct {
 	Image   string
 	URL     string
 	Rank    int
+	// ExtractHTML is the extract with its formatting, when the wiki has it
+	ExtractHTML string
+	// RevisionID and LastEdited are the revision the extract is from,
+	// when the wiki gives them with the summary
+	RevisionID int
+	LastEdited time.Time
+	// WikibaseItem is the Wikidata item ID of the page, like "Q90"
+	WikibaseItem string
+	// Coordinates is the location of the subject of the page, if it has one
+	Coordinates *Coordinates
+}
+
+// Coordinates is a location on Earth, in degrees
+type Coordinates struct {
+	Lat float64
+	Lon float64
 }
 
 // PagelistPage represent normalized structure for an information for a page in a list
@@ -40,91 +55,36 @@ type PagelistPage struct {
 	Info  string
 }
 
-// FetchSummary fetches the summary of a specific Wikipedia page given by its title
-func FetchSummary(title string) (resp []Page, lang string, actualTitle string) {
-	lang, strippedTitle := ParseLanguageFromText(title)
-	safeTitle := prepTitleForURLQuery(strippedTitle)
-
-	url := fmt.Sprintf(wikiRESTEndpoint+wikiRESTsummary, lang, safeTitle)
-	toLog("FetchSummary", url)
-
-	body, readErr := fetchFromAPI(url)
-	if readErr != nil {
-		return getNotFound(), lang, strippedTitle
-	}
-
-	return processRESTApiResult(body, false), lang, strippedTitle
+// FetchSummary fetches the summary of a specific page given by its title.
+// The wiki is picked from the wiki=name or lang=xx expressions in the title.
+func FetchSummary(title string) (resp []Page, wiki Backend, actualTitle string) {
+	wiki, strippedTitle := ParseWikiFromText(title)
+	return wiki.Summary(strippedTitle), wiki, strippedTitle
 }
 
 // FetchRelated fetches the related pages for the given term
-func FetchRelated(term string) (resp []Page, lang string, actualTerm string) {
-	lang, strippedTerm := ParseLanguageFromText(term)
-	safeTitle := prepTitleForURLQuery(strippedTerm)
-
-	url := fmt.Sprintf(wikiRESTEndpoint+wikiRESTrelated, lang, safeTitle)
-	toLog("FetchRelated", "URL: "+url)
-
-	body, readErr := fetchFromAPI(url)
-	if readErr != nil {
-		return getNotFound(), lang, strippedTerm
-	}
-
-	return processRESTApiResult(body, true), lang, strippedTerm
+func FetchRelated(term string) (resp []Page, wiki Backend, actualTerm string) {
+	wiki, strippedTerm := ParseWikiFromText(term)
+	return wiki.Related(strippedTerm), wiki, strippedTerm
 }
 
-// FetchSearch fetches search results from Wikipedia given the search string
-func FetchSearch(searchString string) (resp []Page, lang string, actualSearchString string) {
-	lang, strippedTerm := ParseLanguageFromText(searchString)
-
-	params := url.Values{}
-
-	params.Add("action", "query")
-	params.Add("format", "json")
-	params.Add("prop", "extracts|pageimages|info")
-	params.Add("generator", "search")
-	params.Add("redirects", "1")
-	params.Add("exchars", "250")
-	params.Add("exlimit", "5")
-	params.Add("exintro", "1")
-	params.Add("explaintext", "1")
-	params.Add("inprop", "url")
-	params.Add("gsrlimit", "5")
-	// params.Add("gsrwhat", "text")
-	params.Add("gsrwhat", "nearmatch")
-	params.Add("gsrsearch", strippedTerm)
-
-	url := fmt.Sprintf(wikiActionAPIendpoint, lang) + "?" + params.Encode()
-	toLog("FetchSearch", "URL: "+url)
-
-	body, readErr := fetchFromAPI(url)
-	if readErr != nil {
-		return getNotFound(), lang, strippedTerm
-	}
-
-	return processActionAPIResult(body), lang, strippedTerm
+// FetchSearch fetches search results from the wiki given the search string
+func FetchSearch(searchString string) (resp []Page, wiki Backend, actualSearchString string) {
+	wiki, strippedTerm := ParseWikiFromText(searchString)
+	return wiki.Search(strippedTerm), wiki, strippedTerm
 }
 
-// FetchTopPageviews fetches the top articles by pageview for a given date.
+// FetchTopPageviews fetches the top articles by pageview for a given date,
+// read like in ParseDate. Dates that can't be read are not found.
 // Lang parameter will dictate the Wikipedia that will be searched. If given
 // empty string, will fall back on "en"
 func FetchTopPageviews(datestring string, lang string) (resp []PagelistPage) {
-	t := ParseTimeString(datestring)
-
-	if len(lang) == 0 {
-		lang = "en"
-	}
-
-	// Build the url
-	url := fmt.Sprintf(wikiAnalyticsPageviewsEndpoint, lang, t.Year(), int(t.Month()), t.Day())
-
-	toLog("FetchTopPageviews", "URL: "+url)
-
-	body, readErr := fetchFromAPI(url)
-	if readErr != nil {
+	t, err := ParseDate(datestring, SystemClock.Now())
+	if err != nil {
+		toLog("FetchTopPageviews", "Failed to parse given date string: "+datestring)
 		return []PagelistPage{{"Not found.", "", 0, ""}}
 	}
-
-	return processAnalyticsPageviews(body, lang)
+	return Wikipedia(lang).TopPageviews(t, TopPageviewsOptions{})
 }
 
 // FetchGetGeneralTerm is a general method of fetching a term from Wikipedia,
@@ -149,71 +109,131 @@ func FetchTopPageviews(datestring string, lang string) (resp []PagelistPage) {
 // = Return value
 // The method returns a list of results, and a list of 'sub' results (related pages)
 // so the consumer can display those differently if they wish.
-func FetchGetGeneralTerm(term string) (results []Page, related []Page, lang string, actualTitle string) {
+func FetchGetGeneralTerm(term string) (results []Page, related []Page, wiki Backend, actualTitle string) {
+	wiki, actualTitle = ParseWikiFromText(term)
+	results, related = getGeneralTerm(wiki, actualTitle, true)
+	return results, related, wiki, actualTitle
+}
+
+// FetchGetGeneralTermWithFallback fetches the term like FetchGetGeneralTerm,
+// and if nothing was found, retries in each of the fallback wikis in order.
+// The fallbacks are language codes or names of registered wikis; a
+// fallback=xx,yy expression in the term overrides them, and fallback=none
+// disables them.
+//
+// When the result comes from a fallback wiki, the returned Fallback describes
+// the wiki that was originally requested, and a link back to the article in
+// that wiki's language if one exists. Otherwise, it is nil.
+func FetchGetGeneralTermWithFallback(term string, fallbacks []string) (results []Page, related []Page, wiki Backend, actualTitle string, fallback *Fallback) {
+	requestFallbacks, term := ParseFallbackFromText(term)
+	if requestFallbacks != nil {
+		fallbacks = requestFallbacks
+	}
+
+	results, related, wiki, actualTitle = FetchGetGeneralTerm(term)
+	if results[0].Title != "Not found." {
+		return results, related, wiki, actualTitle, nil
+	}
+
+	for _, name := range fallbacks {
+		fallbackWiki := wikiByName(name)
+		if fallbackWiki.Name() == wiki.Name() {
+			continue
+		}
+		toLog("FetchGetGeneralTermWithFallback trying", fallbackWiki.Name())
+		fallbackResults, fallbackRelated := getGeneralTerm(fallbackWiki, actualTitle, true)
+		if fallbackResults[0].Title == "Not found." {
+			continue
+		}
+
+		fallback = &Fallback{Requested: wiki}
+		if mediaWiki, ok := fallbackWiki.(*MediaWiki); ok && len(fallbackResults) == 1 && fallbackWiki.Language() != wiki.Language() {
+			links := mediaWiki.LanguageLinks(fallbackResults[0].Title, wiki.Language())
+			if len(links) > 0 {
+				fallback.LanguageLink = links[0]
+			}
+		}
+		return fallbackResults, fallbackRelated, fallbackWiki, actualTitle, fallback
+	}
+
+	return results, related, wiki, actualTitle, nil
+}
+
+// Fetch the term from the given wiki, following the process described
+// in FetchGetGeneralTerm. Related pages are only fetched if withRelated is set.
+func getGeneralTerm(wiki Backend, actualTitle string, withRelated bool) (results []Page, related []Page) {
 	relatedPages := []Page{}
-	summaryPages, lang, actualTitle := FetchSummary(term)
-	toLog("FetchGetGeneralTerm term", term)
+	summaryPages := wiki.Summary(actualTitle)
+	toLog("FetchGetGeneralTerm term", actualTitle)
 	if summaryPages[0].Title != "Not found." {
 		toLog("FetchGetGeneralTerm summary found", summaryPages[0].Title)
 		// Page found. Fetch related
-		relatedPages, _, _ = FetchRelated(summaryPages[0].Title)
-		return summaryPages, relatedPages, lang, actualTitle
+		if withRelated && wiki.Supports(FeatureRelated) {
+			relatedPages = wiki.Related(summaryPages[0].Title)
+		}
+		return summaryPages, relatedPages
 	}
 	toLog("FetchGetGeneralTerm summary not found for title", actualTitle)
 
 	// Summary wasn't found. Do a search
-	searchPages, _, _ := FetchSearch(term)
+	searchPages := wiki.Search(actualTitle)
 	if searchPages[0].Title != "Not found." {
 		toLog("FetchGetGeneralTerm search found with "+strconv.Itoa(len(searchPages))+" results", searchPages[0].Title)
 		if len(searchPages) == 1 || strings.ToLower(searchPages[0].Title) == strings.ToLower(actualTitle) {
 			// This is the page we're looking for. Fetch related to the actual title
-			relatedPages, _, _ = FetchRelated(searchPages[0].Title)
+			if withRelated && wiki.Supports(FeatureRelated) {
+				relatedPages = wiki.Related(searchPages[0].Title)
+			}
 
 			// Only return the first page
 			searchPages = append([]Page{}, searchPages[:1]...)
 			toLog("FetchGetGeneralTerm returning first page of search results", searchPages[0].Title)
-			return searchPages, relatedPages, lang, actualTitle
+			return searchPages, relatedPages
 		}
 		// Return the search results
 		toLog("FetchGetGeneralTerm", "Returning full search results")
-		return searchPages, []Page{}, lang, actualTitle
+		return searchPages, []Page{}
 	}
 
 	// Search results not found. Return 'not found'
-	toLog("FetchGetGeneralTerm not found: ", term)
-	return summaryPages, searchPages, lang, actualTitle
+	toLog("FetchGetGeneralTerm not found: ", actualTitle)
+	return summaryPages, searchPages
 }
 
-// ParseTimeString normalizes and then parses the given string into a time object
-func ParseTimeString(datestring string) (parsed time.Time) {
-	datestring = strings.TrimSpace(datestring)
-	fmt.Printf("Parsing time string \"%s\"", datestring)
-	t := time.Now()
-	if len(datestring) != 0 {
-		parsedTime, err := dateparse.ParseAny(datestring)
-		if err != nil {
-			t = time.Now()
-			toLog("ParseTimeString", "Failed to parse given date string: "+datestring)
-			// fmt.Println(err)
-		} else {
-			t = parsedTime
-		}
-	}
-	return t
+// Expressions like "lang=he", "wiki=corp" or "fallback=none"
+var parameterRegexp = regexp.MustCompile(`\S+=\S*`)
+
+// StripParameters removes all the name=value expressions from the text,
+// leaving only the term
+func StripParameters(text string) string {
+	return strings.Join(strings.Fields(parameterRegexp.ReplaceAllString(text, "")), " ")
 }
 
+// Expressions like "lang=he" or "lang=en,fr"
+var languageParameterRegexp = regexp.MustCompile("lang=([[:alpha:]_,-]+)")
+
 // ParseLanguageFromText looks for the lang=xx expression and outputs
 // the language, or defaults to 'en' if language wasn't found.
+// If a list of languages was given, the first one is used.
 func ParseLanguageFromText(text string) (lang string, remainingText string) {
-	r, _ := regexp.Compile("lang=([[:alpha:]_-]+)")
-	match := r.FindStringSubmatch(text)
+	langs, remainingText := ParseLanguagesFromText(text)
+	return langs[0], remainingText
+}
+
+// ParseLanguagesFromText looks for the lang=xx,yy expression and outputs
+// the list of languages, or defaults to 'en' if language wasn't found.
+func ParseLanguagesFromText(text string) (langs []string, remainingText string) {
+	match := languageParameterRegexp.FindStringSubmatch(text)
 
 	if len(match) > 0 {
 		// Remove that from the string
-		newText := strings.TrimSpace(r.ReplaceAllString(text, ""))
-		return match[1], newText
+		newText := strings.TrimSpace(languageParameterRegexp.ReplaceAllString(text, ""))
+		if langs = splitList(match[1]); len(langs) > 0 {
+			return langs, newText
+		}
+		return []string{"en"}, newText
 	}
-	return "en", strings.TrimSpace(text)
+	return []string{"en"}, strings.TrimSpace(text)
 }
 
 // Prepare a given string to be used in a URL query
@@ -230,12 +250,17 @@ func prepTitleForURLQuery(text string) (urlSafeTitle string) {
 // Fetch data from the given API link
 // Return the bytstream for the body of the reply to be processed
 func fetchFromAPI(link string) (body []byte, err error) {
+	return fetchFromAPIContext(context.Background(), link)
+}
+
+// Fetch from the API like fetchFromAPI, giving up when the context is done
+func fetchFromAPIContext(ctx context.Context, link string) (body []byte, err error) {
 	wikiClient := http.Client{
 		Timeout: time.Second * 2, // Maximum of 2 secs
 	}
 	fakeBody := []byte{}
 
-	req, err := http.NewRequest(http.MethodGet, link, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, link, nil)
 	if err != nil {
 		return fakeBody, err
 	}
@@ -265,12 +290,21 @@ func processActionAPIResult(body []byte) (page []Page) {
 
 	collection := []Page{}
 	for _, page := range record.Query.Pages {
+		if page.Pageid == 0 {
+			// Missing or invalid titles come back without a page id
+			continue
+		}
 		collection = append(collection, Page{
-			page.Title,
-			strings.TrimSpace(page.Extract),
-			page.Thumbnail.Source,
-			page.Canonicalurl,
-			page.Index})
+			Title:        page.Title,
+			Extract:      strings.TrimSpace(page.Extract),
+			Image:        page.Thumbnail.Source,
+			URL:          page.Canonicalurl,
+			Rank:         page.Index,
+			WikibaseItem: page.Pageprops.WikibaseItem,
+			Coordinates:  primaryCoordinates(page.Coordinates)})
+	}
+	if len(collection) == 0 {
+		return getNotFound()
 	}
 	sort.SliceStable(collection, func(i, j int) bool {
 		return collection[i].Rank < collection[j].Rank
@@ -293,11 +327,14 @@ func processRESTApiResult(body []byte, isMultiple bool) (page []Page) {
 		collection := []Page{}
 		for _, page := range record.Pages {
 			collection = append(collection, Page{
-				page.Titles.Normalized,
-				strings.TrimSpace(page.Extract),
-				page.Thumbnail.Source,
-				page.ContentUrls.Desktop.Page,
-				0})
+				Title:        page.Titles.Normalized,
+				Extract:      strings.TrimSpace(page.Extract),
+				ExtractHTML:  strings.TrimSpace(page.ExtractHTML),
+				RevisionID:   restRevisionID(page),
+				LastEdited:   restTimestamp(page),
+				Image:        page.Thumbnail.Source,
+				URL:          page.ContentUrls.Desktop.Page,
+				WikibaseItem: page.WikibaseItem})
 		}
 		return collection
 	}
@@ -308,12 +345,21 @@ func processRESTApiResult(body []byte, isMultiple bool) (page []Page) {
 	if jsonErr != nil || record.Title == "Not found." {
 		return getNotFound()
 	}
-	return []Page{{record.Titles.Normalized, strings.TrimSpace(record.Extract), record.Thumbnail.Source, record.ContentUrls.Desktop.Page, 0}}
+	return []Page{{
+		Title:        record.Titles.Normalized,
+		Extract:      strings.TrimSpace(record.Extract),
+		ExtractHTML:  strings.TrimSpace(record.ExtractHTML),
+		RevisionID:   restRevisionID(record),
+		LastEdited:   restTimestamp(record),
+		Image:        record.Thumbnail.Source,
+		URL:          record.ContentUrls.Desktop.Page,
+		WikibaseItem: record.WikibaseItem,
+		Coordinates:  restCoordinates(record)}}
 }
 
 // Process the result from the Wikipedia analytics Pageview API endpoint
 // and return a list representing the pages with their pageview and rank
-func processAnalyticsPageviews(body []byte, lang string) (list []PagelistPage) {
+func processAnalyticsPageviews(body []byte, wiki *MediaWiki) (list []PagelistPage) {
 	record := AnalyticsPageviews{}
 	jsonErr := json.Unmarshal(body, &record)
 	if jsonErr != nil || len(record.Items) == 0 {
@@ -325,13 +371,11 @@ func processAnalyticsPageviews(body []byte, lang string) (list []PagelistPage) {
 	results := record.Items[0].Articles
 	collection := []PagelistPage{}
 	for _, page := range results {
-		articleURL := fmt.Sprintf(wikiBaseArticlePath, lang, url.QueryEscape(page.Article))
-
 		collection = append(collection, PagelistPage{
 			strings.ReplaceAll(page.Article, "_", " "), // Title
-			articleURL,                // URL
-			page.Rank,                 // Rank
-			strconv.Itoa(page.Views)}) // Pageviews, stringified
+			wiki.ArticleURL(page.Article),              // URL
+			page.Rank,                                  // Rank
+			strconv.Itoa(page.Views)})                  // Pageviews, stringified
 	}
 	return collection
 }
@@ -346,22 +390,20 @@ func processAnalyticsPageviews(body []byte, lang string) (list []PagelistPage) {
 // unavailable. If that is the case, this gives the consumer a chance to change
 // the date to a day before or alert the user that they should change their
 // requested date themselves.
+//
+// Only the calendar date of the given time is compared.
+//
+// Deprecated: Use PageviewsAvailability or EditsAvailability, which also
+// account for how late the analytics data comes in.
 func IsDateBeforeUTCToday(requestedDate time.Time) (isBefore bool) {
-	location, err := time.LoadLocation("UTC")
-	if err != nil {
-		return true
-	}
-	utcDate := time.Now().In(location)
-	// Can't do the direct time comparison (time.Before() time.After())
-	// because the actual timestamp doesn't matter, just the year/month/day
-	isBeforeUTC := requestedDate.Year() <= utcDate.Year() && requestedDate.Month() <= utcDate.Month() && requestedDate.Day() < utcDate.Day()
-	toLog("IsDateBeforeUTCToday", "Requested: "+requestedDate.Format(time.RFC822)+", UTC Date: "+utcDate.Format(time.RFC822)+" -> BEFORE: "+strconv.FormatBool(isBeforeUTC))
+	isBeforeUTC := isDateBeforeUTCToday(requestedDate, SystemClock)
+	toLog("IsDateBeforeUTCToday", "Requested: "+requestedDate.Format(time.RFC822)+", UTC Date: "+SystemClock.Now().UTC().Format(time.RFC822)+" -> BEFORE: "+strconv.FormatBool(isBeforeUTC))
 	return isBeforeUTC
 }
 
 // Output the normalized structure with a 'not found' result.
 func getNotFound() (pages []Page) {
-	return []Page{{"Not found.", "", "", "", 0}}
+	return []Page{{Title: "Not found."}}
 }
 
 // Output to a log, including timestamps and context
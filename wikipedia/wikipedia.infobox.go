@@ -0,0 +1,245 @@
+package wikipedia
+
+import (
+	"encoding/json"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// The most fields read from an infobox
+const infoboxFieldsLimit = 20
+
+// The smallest width of an image to be the main image of an infobox,
+// to skip flags and icons
+const infoboxImageMinWidth = 100
+
+// InfoboxField is a single label and value of an infobox
+type InfoboxField struct {
+	Label string
+	Value string
+}
+
+// Infobox is the summary table at the top of an article
+type Infobox struct {
+	Page    Page
+	Image   string
+	Caption string
+	Fields  []InfoboxField
+}
+
+// FetchInfobox reads the first infobox of an article. The article is found
+// the same way FetchGetGeneralTerm does; found is false if it has no infobox.
+func FetchInfobox(term string) (infobox Infobox, wiki Backend, actualTitle string, found bool) {
+	wiki, actualTitle = ParseWikiFromText(term)
+	infobox.Page = getNotFound()[0]
+
+	mediaWiki, ok := wiki.(*MediaWiki)
+	if !ok || len(actualTitle) == 0 {
+		return infobox, wiki, actualTitle, false
+	}
+	results, _ := getGeneralTerm(wiki, actualTitle, false)
+	if len(results) != 1 || results[0].Title == "Not found." {
+		return infobox, wiki, actualTitle, false
+	}
+
+	infobox, found = mediaWiki.Infobox(results[0].Title)
+	infobox.Page = results[0]
+	if len(infobox.Image) == 0 {
+		infobox.Image = results[0].Image
+	}
+	return infobox, wiki, actualTitle, found
+}
+
+// Infobox reads the first infobox of the article with the given title
+func (w *MediaWiki) Infobox(title string) (infobox Infobox, found bool) {
+	return parseInfobox(w.LeadHTML(title))
+}
+
+// LeadHTML is the parsed HTML of the lead section of an article, where
+// infoboxes are
+func (w *MediaWiki) LeadHTML(title string) string {
+	params := url.Values{}
+
+	params.Add("action", "parse")
+	params.Add("format", "json")
+	params.Add("formatversion", "2")
+	params.Add("prop", "text")
+	params.Add("section", "0")
+	params.Add("disableeditsection", "1")
+	params.Add("redirects", "1")
+	params.Add("page", strings.TrimSpace(title))
+
+	url := w.actionAPIURL(params)
+	toLog("LeadHTML", "URL: "+url)
+
+	body, readErr := fetchFromAPI(url)
+	if readErr != nil {
+		return ""
+	}
+
+	record := ActionAPIParseTextResponse{}
+	if jsonErr := json.Unmarshal(body, &record); jsonErr != nil {
+		return ""
+	}
+	return record.Parse.Text
+}
+
+// Parse the first infobox table of an article's HTML into its fields
+func parseInfobox(text string) (infobox Infobox, found bool) {
+	document, err := html.Parse(strings.NewReader(text))
+	if err != nil {
+		return infobox, false
+	}
+	table := findNode(document, func(node *html.Node) bool {
+		return node.DataAtom == atom.Table && hasClass(node, "infobox")
+	})
+	if table == nil {
+		return infobox, false
+	}
+
+	for _, row := range tableRows(table) {
+		label, value := (*html.Node)(nil), (*html.Node)(nil)
+		for cell := row.FirstChild; cell != nil; cell = cell.NextSibling {
+			switch {
+			case cell.DataAtom == atom.Th && label == nil:
+				label = cell
+			case cell.DataAtom == atom.Td && value == nil:
+				value = cell
+			}
+		}
+		if value == nil {
+			continue
+		}
+
+		// Rows without a label hold the images and their captions
+		if label == nil {
+			if len(infobox.Image) == 0 {
+				infobox.Image, infobox.Caption = infoboxImage(value)
+			}
+			continue
+		}
+
+		field := InfoboxField{Label: infoboxText(label, ", "), Value: infoboxText(value, "\n")}
+		if len(field.Label) == 0 || len(field.Value) == 0 {
+			continue
+		}
+		infobox.Fields = append(infobox.Fields, field)
+		if len(infobox.Fields) >= infoboxFieldsLimit {
+			break
+		}
+	}
+	return infobox, len(infobox.Fields) != 0
+}
+
+// Output the rows of a table, leaving out the rows of nested tables
+func tableRows(table *html.Node) (rows []*html.Node) {
+	for child := table.FirstChild; child != nil; child = child.NextSibling {
+		switch child.DataAtom {
+		case atom.Tr:
+			rows = append(rows, child)
+		case atom.Thead, atom.Tbody, atom.Tfoot:
+			rows = append(rows, tableRows(child)...)
+		}
+	}
+	return rows
+}
+
+// Output the first large enough image in a cell, and its caption
+func infoboxImage(cell *html.Node) (image string, caption string) {
+	img := findNode(cell, func(node *html.Node) bool {
+		width, _ := strconv.Atoi(attribute(node, "width"))
+		return node.DataAtom == atom.Img && width >= infoboxImageMinWidth
+	})
+	if img == nil {
+		return "", ""
+	}
+	image = attribute(img, "src")
+	if strings.HasPrefix(image, "//") {
+		image = "https:" + image
+	}
+
+	if node := findNode(cell, func(node *html.Node) bool {
+		return hasClass(node, "infobox-caption")
+	}); node != nil {
+		caption = infoboxText(node, " ")
+	}
+	return image, caption
+}
+
+// References like "[1]" and "[a]" left in the text
+var referenceMarkRegexp = regexp.MustCompile(`\[(?:[0-9]+|[a-z]|note [0-9]+|citation needed)\]`)
+
+// Output the readable text of a cell. Line breaks and list items are
+// joined with the separator; references and hidden parts are left out.
+func infoboxText(node *html.Node, separator string) string {
+	builder := strings.Builder{}
+	var walk func(node *html.Node)
+	walk = func(node *html.Node) {
+		switch node.Type {
+		case html.TextNode:
+			builder.WriteString(node.Data)
+			return
+		case html.ElementNode:
+			switch {
+			case node.DataAtom == atom.Style || node.DataAtom == atom.Script || node.DataAtom == atom.Link:
+				return
+			case node.DataAtom == atom.Sup && hasClass(node, "reference"):
+				return
+			case hasClass(node, "noprint") || strings.Contains(strings.ReplaceAll(attribute(node, "style"), " ", ""), "display:none"):
+				return
+			case node.DataAtom == atom.Br || node.DataAtom == atom.Li || node.DataAtom == atom.P || node.DataAtom == atom.Div:
+				builder.WriteString("\n")
+			}
+		}
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(node)
+
+	lines := []string{}
+	for _, line := range strings.Split(referenceMarkRegexp.ReplaceAllString(builder.String(), ""), "\n") {
+		if line = strings.Join(strings.Fields(line), " "); len(line) != 0 {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, separator)
+}
+
+// Output the first node, depth first, that matches
+func findNode(node *html.Node, matches func(node *html.Node) bool) *html.Node {
+	if node.Type == html.ElementNode && matches(node) {
+		return node
+	}
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		if found := findNode(child, matches); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// Output the value of an attribute of a node, or an empty string
+func attribute(node *html.Node, name string) string {
+	for _, attr := range node.Attr {
+		if attr.Key == name {
+			return attr.Val
+		}
+	}
+	return ""
+}
+
+// Check whether a node has the given class
+func hasClass(node *html.Node, class string) bool {
+	for _, name := range strings.Fields(attribute(node, "class")) {
+		if name == class {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,38 @@
+package wikipedia
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_parseInfobox(t *testing.T) {
+	text := `<div class="mw-parser-output"><table class="infobox ib-settlement vcard"><tbody>
+<tr><th colspan="2" class="infobox-above">Tokyo</th></tr>
+<tr><td colspan="2" class="infobox-image"><a href="/wiki/File:Skyline.jpg"><img src="//upload.wikimedia.org/skyline.jpg" width="300" height="200"></a><div class="infobox-caption">Skyline of <a href="/wiki/Shinjuku">Shinjuku</a></div></td></tr>
+<tr><td colspan="2"><img src="//upload.wikimedia.org/flag.svg" width="23" height="15"></td></tr>
+<tr><th scope="row" class="infobox-label">Country</th><td class="infobox-data"><a href="/wiki/Japan">Japan</a><sup class="reference"><a href="#cite_note-1">[1]</a></sup></td></tr>
+<tr><th scope="row" class="infobox-label">Population <span style="display: none">hidden</span></th><td class="infobox-data">14,047,594<br>(2020)</td></tr>
+<tr><th scope="row" class="infobox-label">Languages</th><td class="infobox-data"><ul><li>Japanese</li><li>English [a]</li></ul></td></tr>
+<tr><th scope="row" class="infobox-label">Website</th><td class="infobox-data"><span class="noprint">edit</span> <a href="https://www.metro.tokyo.lg.jp">metro.tokyo.lg.jp</a></td></tr>
+<tr><td colspan="2"><table class="infobox"><tr><th>Nested</th><td>Ignored</td></tr></table></td></tr>
+</tbody></table><p>Tokyo is the capital of Japan.</p></div>`
+
+	expected := Infobox{
+		Image:   "https://upload.wikimedia.org/skyline.jpg",
+		Caption: "Skyline of Shinjuku",
+		Fields: []InfoboxField{
+			{"Country", "Japan"},
+			{"Population", "14,047,594\n(2020)"},
+			{"Languages", "Japanese\nEnglish"},
+			{"Website", "metro.tokyo.lg.jp"},
+		},
+	}
+	infobox, found := parseInfobox(text)
+	if !found || !reflect.DeepEqual(infobox, expected) {
+		t.Errorf("parseInfobox() = %#v, %v\nExpected:\n %#v", infobox, found, expected)
+	}
+
+	if _, found := parseInfobox(`<p>No infobox here.</p>`); found {
+		t.Errorf("parseInfobox() found an infobox in a page without one")
+	}
+}
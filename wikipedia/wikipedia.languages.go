@@ -0,0 +1,176 @@
+package wikipedia
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LanguageLink is a normalized structure for a link to the same
+// article in another language
+type LanguageLink struct {
+	Lang         string
+	LanguageName string
+	Title        string
+	URL          string
+}
+
+// Fallback describes a result that was found in a fallback wiki
+// instead of the wiki that was requested
+type Fallback struct {
+	// Requested is the wiki that was asked for and had no result
+	Requested Backend
+	// LanguageLink is the article in the requested wiki's language, if it exists
+	LanguageLink LanguageLink
+}
+
+// WikiResult is the result of fetching a term from a single wiki,
+// as part of a request to several wikis
+type WikiResult struct {
+	Wiki    Backend
+	Results []Page
+	// TimedOut is set if the wiki didn't answer before the deadline
+	TimedOut bool
+}
+
+// FetchGetGeneralTermMultiple fetches the term from the Wikipedias of all
+// the given languages at the same time, without related pages. All the
+// parameters, like lang= and fallback=, are left out of the term.
+// Wikis that don't answer within the deadline are marked as timed out, so
+// the consumer can show the partial results, and their requests are
+// cancelled. The output keeps the order of the given languages.
+func FetchGetGeneralTermMultiple(term string, langs []string, deadline time.Duration) (results []WikiResult, actualTitle string) {
+	actualTitle = StripParameters(term)
+	results = make([]WikiResult, len(langs))
+	ctx, cancel := context.WithTimeout(context.Background(), deadline)
+	defer cancel()
+
+	type answer struct {
+		index int
+		pages []Page
+	}
+	answers := make(chan answer, len(langs))
+	for index, lang := range langs {
+		results[index] = WikiResult{Wiki: Wikipedia(lang), TimedOut: true}
+		go func(index int, wiki Backend) {
+			pages, _ := getGeneralTerm(wiki, actualTitle, false)
+			// Cancelled requests look like pages that weren't found
+			if ctx.Err() == nil {
+				answers <- answer{index, pages}
+			}
+		}(index, Wikipedia(lang).withContext(ctx))
+	}
+
+	for received := 0; received < len(langs); received++ {
+		select {
+		case a := <-answers:
+			results[a.index].Results = a.pages
+			results[a.index].TimedOut = false
+		case <-ctx.Done():
+			toLog("FetchGetGeneralTermMultiple", "Deadline reached with "+strconv.Itoa(received)+" of "+strconv.Itoa(len(langs))+" answers")
+			return results, actualTitle
+		}
+	}
+	return results, actualTitle
+}
+
+// ParseFallbackFromText looks for the fallback=xx,yy expression and outputs
+// the list of fallback wikis. The list is nil if there was no expression,
+// and empty for fallback=none.
+func ParseFallbackFromText(text string) (fallbacks []string, remainingText string) {
+	r, _ := regexp.Compile("fallback=([[:alnum:]_.,-]+)")
+	match := r.FindStringSubmatch(text)
+	if len(match) == 0 {
+		return nil, strings.TrimSpace(text)
+	}
+
+	fallbacks = []string{}
+	if match[1] != "none" {
+		fallbacks = splitList(match[1])
+	}
+	return fallbacks, strings.TrimSpace(r.ReplaceAllString(text, ""))
+}
+
+// FetchLanguageLinks finds the article for the given title the same way
+// FetchGetGeneralTerm does, and fetches the links to it in all the other
+// languages, sorted by language code. The found article is "Not found." if
+// there was no single match for the title.
+func FetchLanguageLinks(title string) (links []LanguageLink, page Page, wiki Backend, actualTitle string) {
+	wiki, actualTitle = ParseWikiFromText(title)
+	results, _ := getGeneralTerm(wiki, actualTitle, false)
+	page = results[0]
+	links = []LanguageLink{}
+
+	mediaWiki, ok := wiki.(*MediaWiki)
+	if !ok || len(results) != 1 || page.Title == "Not found." {
+		return links, getNotFound()[0], wiki, actualTitle
+	}
+
+	links = mediaWiki.LanguageLinks(page.Title, "")
+	sort.SliceStable(links, func(i, j int) bool {
+		return links[i].Lang < links[j].Lang
+	})
+	return links, page, wiki, actualTitle
+}
+
+// LanguageLinks fetches the links to the given article in other languages.
+// If lang is given, only the link to that language is requested.
+func (w *MediaWiki) LanguageLinks(title string, lang string) []LanguageLink {
+	params := url.Values{}
+
+	params.Add("action", "query")
+	params.Add("format", "json")
+	params.Add("prop", "langlinks")
+	params.Add("redirects", "1")
+	params.Add("llprop", "url|langname|autonym")
+	params.Add("lllimit", "max")
+	params.Add("titles", strings.TrimSpace(title))
+	if len(lang) != 0 {
+		params.Add("lllang", lang)
+	}
+
+	url := w.actionAPIURL(params)
+	toLog("LanguageLinks", "URL: "+url)
+
+	body, readErr := fetchFromAPI(url)
+	if readErr != nil {
+		return []LanguageLink{}
+	}
+	return processLanguageLinks(body)
+}
+
+// Process the langlinks result from the Action API into a list of links
+func processLanguageLinks(body []byte) (links []LanguageLink) {
+	record := ActionAPILanglinksResponse{}
+	links = []LanguageLink{}
+	if jsonErr := json.Unmarshal(body, &record); jsonErr != nil {
+		return links
+	}
+
+	for _, page := range record.Query.Pages {
+		for _, link := range page.Langlinks {
+			name := link.Autonym
+			if len(name) == 0 {
+				name = link.Langname
+			}
+			links = append(links, LanguageLink{link.Lang, name, link.Title, link.URL})
+		}
+	}
+	return links
+}
+
+// Split a comma separated list, dropping empty items
+func splitList(list string) []string {
+	items := []string{}
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); len(item) != 0 {
+			items = append(items, item)
+		}
+	}
+	return items
+}
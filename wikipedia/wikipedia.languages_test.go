@@ -0,0 +1,52 @@
+package wikipedia
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_ParseFallbackFromText(t *testing.T) {
+	tests := []struct {
+		name              string
+		text              string
+		expectedFallbacks []string
+		expectedText      string
+	}{
+		{"No fallback", "foo lang=he", nil, "foo lang=he"},
+		{"Single fallback", "foo fallback=en lang=he", []string{"en"}, "foo  lang=he"},
+		{"Fallback chain", "fallback=fr,en foo", []string{"fr", "en"}, "foo"},
+		{"Disabled fallback", "foo fallback=none", []string{}, "foo"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fallbacks, text := ParseFallbackFromText(tt.text)
+			if !reflect.DeepEqual(fallbacks, tt.expectedFallbacks) || text != tt.expectedText {
+				t.Errorf("ParseFallbackFromText() = %v, %q, want %v, %q", fallbacks, text, tt.expectedFallbacks, tt.expectedText)
+			}
+		})
+	}
+}
+
+func Test_processLanguageLinks(t *testing.T) {
+	body := []byte(`{"batchcomplete":"","query":{"pages":{"22989":{"pageid":22989,"ns":0,"title":"Paris","langlinks":[{"lang":"he","url":"https://he.wikipedia.org/wiki/%D7%A4%D7%A8%D7%99%D7%96","langname":"Hebrew","autonym":"עברית","*":"פריז"},{"lang":"fr","url":"https://fr.wikipedia.org/wiki/Paris","langname":"French","*":"Paris"}]}}}}`)
+	expected := []LanguageLink{
+		{"he", "עברית", "פריז", "https://he.wikipedia.org/wiki/%D7%A4%D7%A8%D7%99%D7%96"},
+		{"fr", "French", "Paris", "https://fr.wikipedia.org/wiki/Paris"},
+	}
+	if links := processLanguageLinks(body); !reflect.DeepEqual(links, expected) {
+		t.Errorf("processLanguageLinks() = %v\nExpected:\n %v", links, expected)
+	}
+}
+
+func Test_FetchGetGeneralTermMultipleDeadline(t *testing.T) {
+	// With no time at all, every request is cancelled before it answers
+	results, actualTitle := FetchGetGeneralTermMultiple("Paris lang=en,fr fallback=de", []string{"en", "fr"}, 0)
+	if actualTitle != "Paris" {
+		t.Errorf("FetchGetGeneralTermMultiple() title = %q, want Paris", actualTitle)
+	}
+	for _, result := range results {
+		if !result.TimedOut {
+			t.Errorf("FetchGetGeneralTermMultiple() %s didn't time out", result.Wiki.Name())
+		}
+	}
+}
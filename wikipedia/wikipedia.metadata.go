@@ -0,0 +1,152 @@
+package wikipedia
+
+import (
+	"encoding/json"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Names of the protection levels of the edit and move rights
+var protectionLevelNames = map[string]string{
+	"autoconfirmed":     "semi-protected",
+	"extendedconfirmed": "extended-protected",
+	"templateeditor":    "template-protected",
+	"sysop":             "fully protected",
+}
+
+// PageMetadata is information about the current revision of an article
+type PageMetadata struct {
+	Title      string
+	RevisionID int
+	LastEdited time.Time
+	// Length is the size of the page in bytes
+	Length int
+	// Protection is a readable protection level, like "semi-protected",
+	// or empty if the page isn't protected
+	Protection string
+	// AssessmentClass is the quality class WikiProjects gave the
+	// article, like "GA" or "B", if the wiki has assessments
+	AssessmentClass string
+	// PermanentURL links to this revision of the article
+	PermanentURL string
+}
+
+// Metadata fetches the information about the current revision of an
+// article. Found is false if the page doesn't exist.
+func (w *MediaWiki) Metadata(title string) (metadata PageMetadata, found bool) {
+	params := url.Values{}
+
+	params.Add("action", "query")
+	params.Add("format", "json")
+	params.Add("prop", "info|revisions|pageassessments")
+	params.Add("inprop", "protection")
+	params.Add("rvprop", "ids|timestamp")
+	params.Add("redirects", "1")
+	params.Add("titles", strings.TrimSpace(title))
+
+	url := w.actionAPIURL(params)
+	toLog("Metadata", "URL: "+url)
+
+	body, readErr := fetchFromAPI(url)
+	if readErr != nil {
+		return metadata, false
+	}
+	return processMetadata(body, w)
+}
+
+// PermanentURL is the link to a specific revision of an article
+func (w *MediaWiki) PermanentURL(title string, revisionID int) string {
+	script := strings.TrimRight(w.BaseURL, "/") + "/w/index.php"
+	if len(w.ActionAPI) != 0 {
+		script = strings.TrimSuffix(w.ActionAPI, "api.php") + "index.php"
+	}
+	params := url.Values{}
+	params.Add("title", strings.ReplaceAll(title, " ", "_"))
+	params.Add("oldid", strconv.Itoa(revisionID))
+	return script + "?" + params.Encode()
+}
+
+// Output the metadata of the page in an action API response
+func processMetadata(body []byte, wiki *MediaWiki) (metadata PageMetadata, found bool) {
+	record := ActionAPIGeneratorResponse{}
+	if jsonErr := json.Unmarshal(body, &record); jsonErr != nil {
+		return metadata, false
+	}
+
+	for _, page := range record.Query.Pages {
+		if page.Pageid == 0 {
+			continue
+		}
+		metadata = PageMetadata{
+			Title:           page.Title,
+			RevisionID:      page.Lastrevid,
+			Length:          page.Length,
+			Protection:      protectionLevel(page),
+			AssessmentClass: assessmentClass(page),
+		}
+		if len(page.Revisions) > 0 {
+			metadata.RevisionID = page.Revisions[0].Revid
+			metadata.LastEdited = page.Revisions[0].Timestamp
+		}
+		if metadata.RevisionID != 0 {
+			metadata.PermanentURL = wiki.PermanentURL(page.Title, metadata.RevisionID)
+		}
+		return metadata, true
+	}
+	return metadata, false
+}
+
+// Output the readable edit protection level of a page, or its move
+// protection if only moves are restricted
+func protectionLevel(page ActionAPIBaseResponsePageInfo) string {
+	levels := map[string]string{}
+	for _, protection := range page.Protection {
+		levels[protection.Type] = protection.Level
+	}
+	if level, ok := levels["edit"]; ok {
+		if name, ok := protectionLevelNames[level]; ok {
+			return name
+		}
+		return "protected (" + level + ")"
+	}
+	if _, ok := levels["move"]; ok {
+		return "move-protected"
+	}
+	return ""
+}
+
+// Output the assessment class most WikiProjects agree on
+func assessmentClass(page ActionAPIBaseResponsePageInfo) string {
+	counts := map[string]int{}
+	for _, assessment := range page.Pageassessments {
+		if len(assessment.Class) != 0 {
+			counts[assessment.Class]++
+		}
+	}
+	classes := []string{}
+	for class := range counts {
+		classes = append(classes, class)
+	}
+	sort.Slice(classes, func(i, j int) bool {
+		return counts[classes[i]] > counts[classes[j]] || (counts[classes[i]] == counts[classes[j]] && classes[i] < classes[j])
+	})
+	if len(classes) == 0 {
+		return ""
+	}
+	return classes[0]
+}
+
+// Output the revision ID of a REST summary, or 0 if it has none
+func restRevisionID(record PageResponseREST) int {
+	revisionID, _ := strconv.Atoi(record.Revision)
+	return revisionID
+}
+
+// Output the time of the revision of a REST summary, if it has one
+func restTimestamp(record PageResponseREST) time.Time {
+	timestamp, _ := time.Parse(time.RFC3339, record.Timestamp)
+	return timestamp
+}
@@ -0,0 +1,54 @@
+package wikipedia
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func Test_processMetadata(t *testing.T) {
+	body := []byte(`{"batchcomplete":"","query":{"pages":{"30057":{"pageid":30057,"ns":0,"title":"Tokyo","contentmodel":"wikitext","touched":"2020-05-01T10:00:00Z","lastrevid":953000000,"length":181234,"protection":[{"type":"edit","level":"autoconfirmed","expiry":"infinity"},{"type":"move","level":"sysop","expiry":"infinity"}],"revisions":[{"revid":953000000,"parentid":952999999,"timestamp":"2020-04-30T08:15:00Z"}],"pageassessments":{"Japan":{"class":"B","importance":"Top"},"Cities":{"class":"B","importance":"High"},"Olympics":{"class":"C","importance":"Low"}}}}}}`)
+	expected := PageMetadata{
+		Title:           "Tokyo",
+		RevisionID:      953000000,
+		LastEdited:      time.Date(2020, 4, 30, 8, 15, 0, 0, time.UTC),
+		Length:          181234,
+		Protection:      "semi-protected",
+		AssessmentClass: "B",
+		PermanentURL:    "https://en.wikipedia.org/w/index.php?oldid=953000000&title=Tokyo",
+	}
+	metadata, found := processMetadata(body, Wikipedia("en"))
+	if !found || !reflect.DeepEqual(metadata, expected) {
+		t.Errorf("processMetadata() = %#v, %v\nExpected:\n %#v", metadata, found, expected)
+	}
+
+	missing := []byte(`{"batchcomplete":"","query":{"pages":{"-1":{"ns":0,"title":"Nothing here","missing":""}}}}`)
+	if _, found := processMetadata(missing, Wikipedia("en")); found {
+		t.Errorf("processMetadata() found a missing page")
+	}
+}
+
+func Test_protectionLevel(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		expected string
+	}{
+		{"Unprotected", `{"protection":[]}`, ""},
+		{"Fully protected", `{"protection":[{"type":"edit","level":"sysop"}]}`, "fully protected"},
+		{"Move only", `{"protection":[{"type":"move","level":"sysop"}]}`, "move-protected"},
+		{"Unknown level", `{"protection":[{"type":"edit","level":"custom"}]}`, "protected (custom)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page := ActionAPIBaseResponsePageInfo{}
+			if err := json.Unmarshal([]byte(tt.body), &page); err != nil {
+				t.Fatal(err)
+			}
+			if level := protectionLevel(page); level != tt.expected {
+				t.Errorf("protectionLevel() = %q, want %q", level, tt.expected)
+			}
+		})
+	}
+}
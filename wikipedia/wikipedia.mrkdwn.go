@@ -0,0 +1,138 @@
+package wikipedia
+
+import (
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Characters with Unicode subscript and superscript forms
+var (
+	subscripts = map[rune]rune{
+		'0': '₀', '1': '₁', '2': '₂', '3': '₃', '4': '₄', '5': '₅', '6': '₆', '7': '₇', '8': '₈', '9': '₉',
+		'+': '₊', '-': '₋', '−': '₋', '=': '₌', '(': '₍', ')': '₎',
+	}
+	superscripts = map[rune]rune{
+		'0': '⁰', '1': '¹', '2': '²', '3': '³', '4': '⁴', '5': '⁵', '6': '⁶', '7': '⁷', '8': '⁸', '9': '⁹',
+		'+': '⁺', '-': '⁻', '−': '⁻', '=': '⁼', '(': '⁽', ')': '⁾', 'n': 'ⁿ', 'i': 'ⁱ',
+	}
+)
+
+// The wrapper TeX puts around the formulas of math fallbacks
+var displayStyleRegexp = regexp.MustCompile(`^\{\\displaystyle\s*(.*)\}$`)
+
+// EscapeMrkdwn escapes the characters that have a special meaning in
+// Slack messages, so text can't break the message's links and mentions
+func EscapeMrkdwn(text string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(text)
+}
+
+// HTMLToMrkdwn converts the HTML of an extract to Slack's mrkdwn: bold
+// and italics are kept, subscripts and superscripts use their Unicode
+// forms when they have them, and formulas are shown as their TeX source.
+// Everything else is kept as escaped plain text.
+func HTMLToMrkdwn(text string) string {
+	nodes, err := html.ParseFragment(strings.NewReader(text), &html.Node{Type: html.ElementNode, Data: "div", DataAtom: atom.Div})
+	if err != nil {
+		return EscapeMrkdwn(text)
+	}
+
+	builder := strings.Builder{}
+	for _, node := range nodes {
+		builder.WriteString(nodeToMrkdwn(node))
+	}
+
+	paragraphs := []string{}
+	for _, paragraph := range strings.Split(builder.String(), "\n\n") {
+		if paragraph = strings.TrimSpace(paragraph); len(paragraph) != 0 {
+			paragraphs = append(paragraphs, paragraph)
+		}
+	}
+	return strings.Join(paragraphs, "\n\n")
+}
+
+// Output the mrkdwn of a node and its children
+func nodeToMrkdwn(node *html.Node) string {
+	if node.Type == html.TextNode {
+		return EscapeMrkdwn(node.Data)
+	}
+	if node.Type != html.ElementNode {
+		return ""
+	}
+
+	// Formulas: the hidden MathML has the TeX source, and so does the
+	// alt text of the image that is shown instead
+	if node.DataAtom == atom.Math || (node.DataAtom == atom.Img && hasClass(node, "mwe-math-fallback-image-inline")) {
+		tex := attribute(node, "alttext")
+		if len(tex) == 0 {
+			tex = attribute(node, "alt")
+		}
+		if match := displayStyleRegexp.FindStringSubmatch(strings.TrimSpace(tex)); match != nil {
+			tex = match[1]
+		}
+		return "`" + strings.ReplaceAll(EscapeMrkdwn(strings.TrimSpace(tex)), "`", "'") + "`"
+	}
+	if hasClass(node, "mwe-math-element") {
+		if math := findNode(node, func(child *html.Node) bool {
+			return child.DataAtom == atom.Math || (child.DataAtom == atom.Img && hasClass(child, "mwe-math-fallback-image-inline"))
+		}); math != nil {
+			return nodeToMrkdwn(math)
+		}
+	}
+	if node.DataAtom == atom.Style || node.DataAtom == atom.Script || strings.Contains(strings.ReplaceAll(attribute(node, "style"), " ", ""), "display:none") {
+		return ""
+	}
+
+	children := strings.Builder{}
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		children.WriteString(nodeToMrkdwn(child))
+	}
+	content := children.String()
+
+	switch node.DataAtom {
+	case atom.B, atom.Strong:
+		return wrapMrkdwn(content, "*")
+	case atom.I, atom.Em:
+		return wrapMrkdwn(content, "_")
+	case atom.Sub:
+		return scriptText(content, subscripts, "_{", "}")
+	case atom.Sup:
+		return scriptText(content, superscripts, "^{", "}")
+	case atom.Br:
+		return "\n"
+	case atom.P, atom.Div, atom.Ul, atom.Ol:
+		return "\n\n" + content + "\n\n"
+	case atom.Li:
+		return "• " + strings.TrimSpace(content) + "\n"
+	}
+	return content
+}
+
+// Wrap text in a formatting marker. Slack only formats markers next to
+// the text, so surrounding spaces are moved outside.
+func wrapMrkdwn(text string, marker string) string {
+	trimmed := strings.TrimSpace(text)
+	if len(trimmed) == 0 || strings.Contains(trimmed, marker) {
+		return text
+	}
+	leading := text[:strings.Index(text, trimmed)]
+	trailing := text[len(leading)+len(trimmed):]
+	return leading + marker + trimmed + marker + trailing
+}
+
+// Output text in its Unicode subscript or superscript form, or wrapped in
+// the fallback markers if any of its characters have no such form
+func scriptText(text string, forms map[rune]rune, prefix string, suffix string) string {
+	text = strings.TrimSpace(text)
+	converted := strings.Builder{}
+	for _, r := range text {
+		form, ok := forms[r]
+		if !ok {
+			return prefix + text + suffix
+		}
+		converted.WriteRune(form)
+	}
+	return converted.String()
+}
@@ -0,0 +1,34 @@
+package wikipedia
+
+import "testing"
+
+func Test_HTMLToMrkdwn(t *testing.T) {
+	tests := []struct {
+		name     string
+		html     string
+		expected string
+	}{
+		{"Plain text", "<p>Tokyo is a city.</p>", "Tokyo is a city."},
+		{"Bold and italics", "<p><b>Tokyo</b> is <i> the capital </i>of Japan.</p>", "*Tokyo* is  _the capital_ of Japan."},
+		{"Subscripts", "<p><b>Water</b> (H<sub>2</sub>O)</p>", "*Water* (H₂O)"},
+		{"Superscripts", "<p>E = mc<sup>2</sup>, x<sup>a</sup></p>", "E = mc², x^{a}"},
+		{"Special characters", "<p>AT&amp;T &lt;b&gt; 1 &lt; 2</p>", "AT&amp;T &lt;b&gt; 1 &lt; 2"},
+		{"Paragraphs", "<p>First.</p><p>Second.</p>", "First.\n\nSecond."},
+		{"Math fallback", `<p>The value <span class="mwe-math-element"><span class="mwe-math-mathml-inline" style="display: none;"><math alttext="{\displaystyle x^{2}}"><mi>x</mi></math></span><img src="x.svg" class="mwe-math-fallback-image-inline" alt="{\displaystyle x^{2}}"></span> grows.</p>`, "The value `x^{2}` grows."},
+		{"Math image only", `<img class="mwe-math-fallback-image-inline" alt="{\displaystyle \pi }">`, "`\\pi`"},
+		{"Spans", `<p><span class="nowrap">New York</span></p>`, "New York"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if text := HTMLToMrkdwn(tt.html); text != tt.expected {
+				t.Errorf("HTMLToMrkdwn() = %q, want %q", text, tt.expected)
+			}
+		})
+	}
+}
+
+func Test_EscapeMrkdwn(t *testing.T) {
+	if text := EscapeMrkdwn("<script> & <b>"); text != "&lt;script&gt; &amp; &lt;b&gt;" {
+		t.Errorf("EscapeMrkdwn() = %q", text)
+	}
+}
@@ -0,0 +1,322 @@
+package wikipedia
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var wikiAnalyticsArticleEndpoint = "https://wikimedia.org/api/rest_v1/metrics/pageviews/per-article/%s/%s/%s/%s/%s/%s/%s" // project/access/agent/article/granularity/start/end
+
+// Number of days and months shown when no range is requested
+const (
+	defaultPageviewsDays   = 30
+	defaultPageviewsMonths = 12
+)
+
+// PageviewsDataStart is the first day the analytics API has pageviews for
+var PageviewsDataStart = time.Date(2015, 7, 1, 0, 0, 0, 0, time.UTC)
+
+// PageviewsRangeMaxDays is the longest range of days the pageviews of an
+// article are fetched for, about ten years
+const PageviewsRangeMaxDays = 10 * 366
+
+// PageviewsGranularities are the supported granularities, the first being the default
+var PageviewsGranularities = []string{"daily", "monthly"}
+
+// PageviewsAccess are the supported access methods, the first being the default
+var PageviewsAccess = []string{"all-access", "desktop", "mobile-app", "mobile-web"}
+
+// PageviewsAgents are the supported agent types, the first being the default.
+// Only people are counted unless asked otherwise.
+var PageviewsAgents = []string{"user", "all-agents", "spider", "automated"}
+
+// Options like "access=mobile-web", "agent=spider" or "granularity=monthly"
+var pageviewsOptionRegexp = regexp.MustCompile(`(?i)(?:^|\s)(granularity|access|agent)=(\S+)`)
+
+// A granularity at the end of the text, like "Tokyo monthly"
+var pageviewsGranularityRegexp = regexp.MustCompile(`(?i)\s+(daily|monthly)$`)
+
+// PageviewsQuery is what the pageviews of an article are requested for
+type PageviewsQuery struct {
+	Range       DateRange
+	Granularity string
+	Access      string
+	Agent       string
+}
+
+// PageviewPoint is the number of views on a day, or in a month
+type PageviewPoint struct {
+	Date  time.Time
+	Views int
+}
+
+// ArticleViews are the pageviews of an article over a range of days, with
+// the views of the period of the same length before them to compare to
+type ArticleViews struct {
+	Page     Page
+	Query    PageviewsQuery
+	Points   []PageviewPoint
+	Previous []PageviewPoint
+}
+
+// PageviewStats are the totals of ArticleViews
+type PageviewStats struct {
+	Total   int
+	Average int
+	Peak    PageviewPoint
+	// PreviousTotal is the total of the period before the range
+	PreviousTotal int
+	// Change is the difference to the previous period in percent. It is
+	// only set if there were views in the previous period.
+	Change    float64
+	HasChange bool
+}
+
+// ParsePageviewsQuery reads the options, granularity and range of days from
+// the text, like "Tokyo monthly last 2 years access=mobile-web", and outputs
+// them with the rest of the text. Unknown option values are ignored. Without
+// a range, the last 30 days or the last 12 full months are used. Ranges
+// are cut down to the days from PageviewsDataStart to the day before now,
+// and to at most PageviewsRangeMaxDays of them. Monthly ranges are widened
+// to start at the beginning of their first month.
+func ParsePageviewsQuery(text string, now time.Time) (query PageviewsQuery, remainingText string) {
+	query = PageviewsQuery{
+		Granularity: PageviewsGranularities[0],
+		Access:      PageviewsAccess[0],
+		Agent:       PageviewsAgents[0],
+	}
+	remainingText = text
+	for _, match := range pageviewsOptionRegexp.FindAllStringSubmatch(text, -1) {
+		remainingText = strings.Replace(remainingText, match[0], "", 1)
+		value := strings.ToLower(match[2])
+		switch strings.ToLower(match[1]) {
+		case "granularity":
+			query.Granularity = pickOption(value, PageviewsGranularities, query.Granularity)
+		case "access":
+			query.Access = pickOption(value, PageviewsAccess, query.Access)
+		case "agent":
+			query.Agent = pickOption(value, PageviewsAgents, query.Agent)
+		}
+	}
+	remainingText = strings.TrimSpace(remainingText)
+
+	// The granularity can be given before or after the range
+	if match := pageviewsGranularityRegexp.FindStringSubmatch(remainingText); match != nil {
+		query.Granularity = strings.ToLower(match[1])
+		remainingText = strings.TrimSpace(strings.TrimSuffix(remainingText, match[0]))
+	}
+	dateRange, withoutRange, hasRange := ParseDateRange(remainingText, now)
+	// Titles like "May 1968" are not ranges
+	if hasRange && len(withoutRange) != 0 {
+		remainingText = withoutRange
+	} else {
+		hasRange = false
+	}
+	if match := pageviewsGranularityRegexp.FindStringSubmatch(remainingText); match != nil {
+		query.Granularity = strings.ToLower(match[1])
+		remainingText = strings.TrimSpace(strings.TrimSuffix(remainingText, match[0]))
+	}
+
+	switch {
+	case !hasRange && query.Granularity == "monthly":
+		today := utcDay(now.UTC())
+		dateRange = DateRange{today.AddDate(0, -defaultPageviewsMonths, 0), today.AddDate(0, 0, -1)}
+	case !hasRange:
+		dateRange = LastDays(defaultPageviewsDays, now)
+	}
+	dateRange = clampPageviewsRange(dateRange, now)
+	if query.Granularity == "monthly" {
+		dateRange = fullMonths(dateRange)
+	}
+	query.Range = dateRange
+	return query, remainingText
+}
+
+// Cut a range down to the days there can be pageviews for, from
+// PageviewsDataStart to the day before now, and to the latest
+// PageviewsRangeMaxDays of them
+func clampPageviewsRange(dateRange DateRange, now time.Time) DateRange {
+	if yesterday := utcDay(now.UTC()).AddDate(0, 0, -1); dateRange.End.After(yesterday) {
+		dateRange.End = yesterday
+	}
+	if dateRange.Start.After(dateRange.End) {
+		dateRange.Start = dateRange.End
+	}
+	if dateRange.Start.Before(PageviewsDataStart) && !dateRange.End.Before(PageviewsDataStart) {
+		dateRange.Start = PageviewsDataStart
+	}
+	if dateRange.Days() > PageviewsRangeMaxDays {
+		dateRange.Start = dateRange.End.AddDate(0, 0, 1-PageviewsRangeMaxDays)
+	}
+	return dateRange
+}
+
+// Previous is the query for the period of the same length right before this one
+func (q PageviewsQuery) Previous() PageviewsQuery {
+	previous := q
+	if q.Granularity == "monthly" {
+		months := len(q.buckets())
+		previous.Range = DateRange{q.Range.Start.AddDate(0, -months, 0), q.Range.Start.AddDate(0, 0, -1)}
+		return previous
+	}
+	previous.Range = q.Range.Previous()
+	return previous
+}
+
+// ArticlePageviews fetches the pageviews of an article. Days or months
+// without views are included with no views.
+func (w *MediaWiki) ArticlePageviews(title string, query PageviewsQuery) (points []PageviewPoint, found bool) {
+	if !w.Supports(FeaturePageviews) {
+		return points, false
+	}
+
+	url := fmt.Sprintf(wikiAnalyticsArticleEndpoint,
+		w.PageviewsProject, query.Access, query.Agent,
+		url.PathEscape(strings.ReplaceAll(strings.TrimSpace(title), " ", "_")),
+		query.Granularity, query.Range.Start.Format("20060102"), query.Range.End.Format("20060102"))
+	toLog("ArticlePageviews", "URL: "+url)
+
+	body, readErr := fetchFromAPI(url)
+	if readErr != nil {
+		return points, false
+	}
+	return processArticlePageviews(body, query)
+}
+
+// FetchArticlePageviews fetches the pageviews of the article in the text,
+// and of the period before them, for the range and options given after the
+// title. The article is found the same way FetchGetGeneralTerm does, so the
+// views are those of the article that redirects point to. Relative dates
+// are read from now, like in ParseDate.
+func FetchArticlePageviews(text string, now time.Time) (views ArticleViews, wiki Backend, actualTitle string, found bool) {
+	wiki, text = ParseWikiFromText(text)
+	query, actualTitle := ParsePageviewsQuery(text, now)
+	views.Query = query
+
+	mediaWiki, ok := wiki.(*MediaWiki)
+	if !ok || !mediaWiki.Supports(FeaturePageviews) || len(actualTitle) == 0 {
+		return views, wiki, actualTitle, false
+	}
+	views, found = fetchArticleViews(mediaWiki, actualTitle, query)
+	return views, wiki, actualTitle, found
+}
+
+// Find the article with the given title the same way FetchGetGeneralTerm
+// does, and fetch its views and those of the period before in one request
+func fetchArticleViews(wiki *MediaWiki, title string, query PageviewsQuery) (views ArticleViews, found bool) {
+	views.Query = query
+	results, _ := getGeneralTerm(wiki, title, false)
+	if len(results) != 1 || results[0].Title == "Not found." {
+		return views, false
+	}
+	views.Page = results[0]
+
+	both := query
+	both.Range = DateRange{query.Previous().Range.Start, query.Range.End}
+	points, found := wiki.ArticlePageviews(views.Page.Title, both)
+	if !found {
+		return views, false
+	}
+	for _, point := range points {
+		if query.Range.Contains(point.Date) {
+			views.Points = append(views.Points, point)
+		} else {
+			views.Previous = append(views.Previous, point)
+		}
+	}
+	return views, true
+}
+
+// Stats outputs the total, average and peak of the views, and how they
+// changed from the period before
+func (v ArticleViews) Stats() (stats PageviewStats) {
+	for _, point := range v.Points {
+		stats.Total += point.Views
+		if point.Views > stats.Peak.Views || stats.Peak.Date.IsZero() {
+			stats.Peak = point
+		}
+	}
+	if len(v.Points) != 0 {
+		stats.Average = stats.Total / len(v.Points)
+	}
+	for _, point := range v.Previous {
+		stats.PreviousTotal += point.Views
+	}
+	if stats.PreviousTotal != 0 {
+		stats.Change = float64(stats.Total-stats.PreviousTotal) / float64(stats.PreviousTotal) * 100
+		stats.HasChange = true
+	}
+	return stats
+}
+
+// Output the days, or first days of the months, that the query has views for
+func (q PageviewsQuery) buckets() (dates []time.Time) {
+	for date := q.Range.Start; !date.After(q.Range.End); {
+		dates = append(dates, date)
+		if q.Granularity == "monthly" {
+			date = date.AddDate(0, 1, 0)
+		} else {
+			date = date.AddDate(0, 0, 1)
+		}
+	}
+	return dates
+}
+
+// Process the result from the Wikipedia analytics per-article endpoint
+// and return the views for each day or month of the query
+func processArticlePageviews(body []byte, query PageviewsQuery) (points []PageviewPoint, found bool) {
+	record := AnalyticsArticlePageviews{}
+	jsonErr := json.Unmarshal(body, &record)
+	if jsonErr != nil {
+		return points, false
+	}
+	// Articles without views in the range are "Not found." rather than empty
+	if len(record.Items) == 0 && record.Title != "Not found." {
+		if len(record.Detail) != 0 {
+			toLog("processArticlePageviews", "Error fetching. Details: "+record.Detail)
+		}
+		return points, false
+	}
+
+	views := map[time.Time]int{}
+	for _, item := range record.Items {
+		date, err := time.Parse("2006010215", item.Timestamp)
+		if err != nil {
+			continue
+		}
+		views[utcDay(date)] += item.Views
+	}
+	for _, date := range query.buckets() {
+		points = append(points, PageviewPoint{date, views[date]})
+	}
+	return points, true
+}
+
+// Output the option if it is one of the allowed values, or the fallback.
+// "all" is short for the all-access and all-agents values.
+func pickOption(value string, allowed []string, fallback string) string {
+	for _, option := range allowed {
+		if value == option || (value == "all" && strings.HasPrefix(option, "all-")) {
+			return option
+		}
+	}
+	return fallback
+}
+
+// Output the range of whole months from the month the given range starts
+// in to the last month that ends within it
+func fullMonths(r DateRange) DateRange {
+	start := time.Date(r.Start.Year(), r.Start.Month(), 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(r.End.Year(), r.End.Month()+1, 0, 0, 0, 0, 0, time.UTC)
+	if !end.Equal(r.End) {
+		end = time.Date(r.End.Year(), r.End.Month(), 0, 0, 0, 0, 0, time.UTC)
+	}
+	if end.Before(start) {
+		end = start.AddDate(0, 1, -1)
+	}
+	return DateRange{start, end}
+}
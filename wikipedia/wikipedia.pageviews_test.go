@@ -0,0 +1,99 @@
+package wikipedia
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func Test_ParsePageviewsQuery(t *testing.T) {
+	now := time.Date(2020, 3, 15, 18, 30, 0, 0, time.UTC)
+	tests := []struct {
+		name          string
+		text          string
+		expectedQuery string
+		expectedText  string
+	}{
+		{"Defaults", "Tokyo", "Feb 14 2020 – Mar 14 2020 daily all-access user", "Tokyo"},
+		{"Options", "Tokyo access=mobile-web agent=all 7d", "Mar 08 2020 – Mar 14 2020 daily mobile-web all-agents", "Tokyo"},
+		{"Unknown option value", "Tokyo access=phone 7d", "Mar 08 2020 – Mar 14 2020 daily all-access user", "Tokyo"},
+		{"Monthly default", "Tokyo monthly", "Mar 01 2019 – Feb 29 2020 monthly all-access user", "Tokyo"},
+		{"Monthly before the range", "Tokyo monthly last 3 months", "Dec 01 2019 – Feb 29 2020 monthly all-access user", "Tokyo"},
+		{"Monthly after the range", "Tokyo 2020-01-10..2020-02-29 monthly", "Jan 01 2020 – Feb 29 2020 monthly all-access user", "Tokyo"},
+		{"Month as the title", "May 1968", "Feb 14 2020 – Mar 14 2020 daily all-access user", "May 1968"},
+		{"Granularity in the title", "Daily Mail", "Feb 14 2020 – Mar 14 2020 daily all-access user", "Daily Mail"},
+		{"Before the data starts", "Tokyo last 500 years", "Jul 01 2015 – Mar 14 2020 daily all-access user", "Tokyo"},
+		{"In the future", "Tokyo 2020-03-01..2999-12-31", "Mar 01 2020 – Mar 14 2020 daily all-access user", "Tokyo"},
+		{"Only in the future", "Tokyo 2021-01-01..2021-02-01", "Mar 14 2020 daily all-access user", "Tokyo"},
+		{"Monthly before the data starts", "Tokyo monthly last 20 years", "Jul 01 2015 – Feb 29 2020 monthly all-access user", "Tokyo"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, text := ParsePageviewsQuery(tt.text, now)
+			summary := query.Range.String() + " " + query.Granularity + " " + query.Access + " " + query.Agent
+			if summary != tt.expectedQuery || text != tt.expectedText {
+				t.Errorf("ParsePageviewsQuery() = %q, %q, want %q, %q", summary, text, tt.expectedQuery, tt.expectedText)
+			}
+		})
+	}
+}
+
+func Test_PageviewsQueryPrevious(t *testing.T) {
+	monthly := PageviewsQuery{Range: DateRange{time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2020, 2, 29, 0, 0, 0, 0, time.UTC)}, Granularity: "monthly"}
+	if previous := monthly.Previous().Range.String(); previous != "Nov 01 2019 – Dec 31 2019" {
+		t.Errorf("Previous() = %q", previous)
+	}
+}
+
+func Test_processArticlePageviews(t *testing.T) {
+	query := PageviewsQuery{Range: DateRange{time.Date(2020, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2020, 3, 3, 0, 0, 0, 0, time.UTC)}, Granularity: "daily"}
+	tests := []struct {
+		name          string
+		body          string
+		expected      []int
+		expectedFound bool
+	}{
+		{
+			"Missing days have no views",
+			`{"items":[{"project":"en.wikipedia","article":"Tokyo","granularity":"daily","timestamp":"2020030100","access":"all-access","agent":"user","views":120},{"project":"en.wikipedia","article":"Tokyo","granularity":"daily","timestamp":"2020030300","access":"all-access","agent":"user","views":80}]}`,
+			[]int{120, 0, 80},
+			true,
+		},
+		{
+			"No views at all",
+			`{"type":"https://mediawiki.org/wiki/HyperSwitch/errors/not_found","title":"Not found.","method":"get","detail":"The date(s) you used are valid, but we either do not have data for those date(s), or the project you asked for is not loaded yet."}`,
+			[]int{0, 0, 0},
+			true,
+		},
+		{
+			"Error",
+			`{"type":"https://mediawiki.org/wiki/HyperSwitch/errors/invalid_request","title":"Invalid parameters","detail":"start timestamp is invalid"}`,
+			nil,
+			false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			points, found := processArticlePageviews([]byte(tt.body), query)
+			var views []int
+			for _, point := range points {
+				views = append(views, point.Views)
+			}
+			if found != tt.expectedFound || !reflect.DeepEqual(views, tt.expected) {
+				t.Errorf("processArticlePageviews() = %v, %v, want %v, %v", views, found, tt.expected, tt.expectedFound)
+			}
+		})
+	}
+}
+
+func Test_ArticleViewsStats(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2020, 3, d, 0, 0, 0, 0, time.UTC) }
+	views := ArticleViews{
+		Points:   []PageviewPoint{{day(3), 100}, {day(4), 300}, {day(5), 200}},
+		Previous: []PageviewPoint{{day(1), 200}, {day(2), 200}},
+	}
+	expected := PageviewStats{Total: 600, Average: 200, Peak: PageviewPoint{day(4), 300}, PreviousTotal: 400, Change: 50, HasChange: true}
+	if stats := views.Stats(); !reflect.DeepEqual(stats, expected) {
+		t.Errorf("Stats() = %+v, want %+v", stats, expected)
+	}
+}
@@ -0,0 +1,155 @@
+package wikipedia
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DateRange is a span of whole days in UTC, including both ends
+type DateRange struct {
+	Start time.Time
+	End   time.Time
+}
+
+// Ranges like "2020-03-01..2020-03-07" or "2020-03-01 to 2020-03-07"
+var explicitRangeRegexp = regexp.MustCompile(`(?i)(?:^|\s)(\d{4}-\d{2}-\d{2})\s*(?:\.\.|to)\s*(\d{4}-\d{2}-\d{2})$`)
+
+// Ranges like "last 30 days", "90d" or "12 months"
+var relativeRangeRegexp = regexp.MustCompile(`(?i)(?:^|\s)(?:last\s+)?(\d+)\s*(d|days?|w|weeks?|m|months?|y|years?)$`)
+
+// Ranges like "last week" or "past month"
+var lastPeriodRegexp = regexp.MustCompile(`(?i)(?:^|\s)(?:last|past)\s+(week|month|year)$`)
+
+// Months like "March 2020" or "Mar. 2020"
+var monthRangeRegexp = regexp.MustCompile(`(?i)(?:^|\s)(jan(?:uary)?|feb(?:ruary)?|mar(?:ch)?|apr(?:il)?|may|june?|july?|aug(?:ust)?|sep(?:t(?:ember)?)?|oct(?:ober)?|nov(?:ember)?|dec(?:ember)?)\.?\s+(\d{4})$`)
+
+// NewDateRange creates the range between two days, in any order
+func NewDateRange(start time.Time, end time.Time) DateRange {
+	start, end = utcDay(start), utcDay(end)
+	if end.Before(start) {
+		start, end = end, start
+	}
+	return DateRange{start, end}
+}
+
+// ParseDateRange looks for a range of days at the end of the text, like
+// "last 30 days", "12m", "March 2020" or "2020-03-01..2020-03-07", and
+// outputs it with the rest of the text. Relative ranges end on the day
+// before now, the last full day in UTC, except "last month" and "last year",
+// which are the calendar month and year before now. Found is false if the
+// text has no range.
+func ParseDateRange(text string, now time.Time) (dateRange DateRange, remainingText string, found bool) {
+	text = strings.TrimSpace(text)
+	if match := explicitRangeRegexp.FindStringSubmatch(text); match != nil {
+		start, startErr := time.Parse("2006-01-02", match[1])
+		end, endErr := time.Parse("2006-01-02", match[2])
+		if startErr == nil && endErr == nil {
+			return NewDateRange(start, end), strings.TrimSpace(strings.Replace(text, match[0], "", 1)), true
+		}
+	}
+
+	if match := monthRangeRegexp.FindStringSubmatch(text); match != nil {
+		month, monthErr := time.Parse("Jan", strings.Title(strings.ToLower(match[1][:3])))
+		year, _ := strconv.Atoi(match[2])
+		if monthErr == nil {
+			start := time.Date(year, month.Month(), 1, 0, 0, 0, 0, time.UTC)
+			return DateRange{start, start.AddDate(0, 1, -1)}, strings.TrimSpace(strings.Replace(text, match[0], "", 1)), true
+		}
+	}
+
+	if match := lastPeriodRegexp.FindStringSubmatch(text); match != nil {
+		today := utcDay(now.UTC())
+		dateRange := LastDays(7, now)
+		switch strings.ToLower(match[1]) {
+		case "month":
+			start := time.Date(today.Year(), today.Month()-1, 1, 0, 0, 0, 0, time.UTC)
+			dateRange = DateRange{start, start.AddDate(0, 1, -1)}
+		case "year":
+			start := time.Date(today.Year()-1, 1, 1, 0, 0, 0, 0, time.UTC)
+			dateRange = DateRange{start, start.AddDate(1, 0, -1)}
+		}
+		return dateRange, strings.TrimSpace(strings.Replace(text, match[0], "", 1)), true
+	}
+
+	if match := relativeRangeRegexp.FindStringSubmatch(text); match != nil {
+		count, _ := strconv.Atoi(match[1])
+		if count > 0 {
+			end := utcDay(now.UTC()).AddDate(0, 0, -1)
+			start := end
+			switch strings.ToLower(match[2])[0] {
+			case 'd':
+				start = end.AddDate(0, 0, -(count - 1))
+			case 'w':
+				start = end.AddDate(0, 0, -(count*7 - 1))
+			case 'm':
+				start = end.AddDate(0, -count, 1)
+			case 'y':
+				start = end.AddDate(-count, 0, 1)
+			}
+			return DateRange{start, end}, strings.TrimSpace(strings.Replace(text, match[0], "", 1)), true
+		}
+	}
+	return DateRange{}, text, false
+}
+
+// LastDays is the range of the given number of full days before now
+func LastDays(days int, now time.Time) DateRange {
+	end := utcDay(now.UTC()).AddDate(0, 0, -1)
+	return DateRange{end.AddDate(0, 0, -(days - 1)), end}
+}
+
+// Days outputs the number of days in the range. The calendar dates are
+// counted, since a time.Duration can't span more than about 292 years.
+func (r DateRange) Days() int {
+	const secondsPerDay = 24 * 60 * 60
+	return int((utcDay(r.End).Unix()-utcDay(r.Start).Unix())/secondsPerDay) + 1
+}
+
+// Previous is the range of the same length right before this one
+func (r DateRange) Previous() DateRange {
+	end := r.Start.AddDate(0, 0, -1)
+	return DateRange{end.AddDate(0, 0, -(r.Days() - 1)), end}
+}
+
+// Contains checks whether a time is on one of the days of the range
+func (r DateRange) Contains(t time.Time) bool {
+	day := utcDay(t)
+	return !day.Before(r.Start) && !day.After(r.End)
+}
+
+// String formats the range like "Mar 01 2020 – Mar 07 2020"
+func (r DateRange) String() string {
+	if r.Start.Equal(r.End) {
+		return r.Start.Format("Jan 02 2006")
+	}
+	return fmt.Sprintf("%s – %s", r.Start.Format("Jan 02 2006"), r.End.Format("Jan 02 2006"))
+}
+
+// IsWholeMonth checks whether the range is exactly one calendar month
+func (r DateRange) IsWholeMonth() bool {
+	return r.Start.Day() == 1 && r.End.Equal(r.Start.AddDate(0, 1, -1))
+}
+
+// Output the whole calendar months and the single days that make up the
+// range, for the analytics lists that are only by day or by month
+func rangeSegments(dateRange DateRange) (segments []DateRange) {
+	for day := dateRange.Start; !day.After(dateRange.End); {
+		month := DateRange{day, day.AddDate(0, 1, -1)}
+		if month.IsWholeMonth() && !month.End.After(dateRange.End) {
+			segments = append(segments, month)
+			day = month.End.AddDate(0, 0, 1)
+			continue
+		}
+		segments = append(segments, DateRange{day, day})
+		day = day.AddDate(0, 0, 1)
+	}
+	return segments
+}
+
+// Output the start of the calendar day of a time, as a UTC date
+func utcDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,90 @@
+package wikipedia
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func Test_ParseDateRange(t *testing.T) {
+	now := time.Date(2020, 3, 15, 18, 30, 0, 0, time.UTC)
+	tests := []struct {
+		name          string
+		text          string
+		expectedRange string
+		expectedText  string
+		expectedFound bool
+	}{
+		{"No range", "Tokyo", "", "Tokyo", false},
+		{"Explicit range", "Tokyo 2020-03-01..2020-03-07", "Mar 01 2020 – Mar 07 2020", "Tokyo", true},
+		{"Explicit range with to", "Tokyo 2020-03-07 to 2020-03-01", "Mar 01 2020 – Mar 07 2020", "Tokyo", true},
+		{"Last days", "Tokyo last 7 days", "Mar 08 2020 – Mar 14 2020", "Tokyo", true},
+		{"Short days", "Tokyo 90d", "Dec 16 2019 – Mar 14 2020", "Tokyo", true},
+		{"Weeks", "Tokyo 2 weeks", "Mar 01 2020 – Mar 14 2020", "Tokyo", true},
+		{"Months", "Tokyo last 1 month", "Feb 15 2020 – Mar 14 2020", "Tokyo", true},
+		{"Years", "Tokyo 1y", "Mar 15 2019 – Mar 14 2020", "Tokyo", true},
+		{"Month", "Tokyo March 2020", "Mar 01 2020 – Mar 31 2020", "Tokyo", true},
+		{"Short month", "Tokyo Feb. 2020", "Feb 01 2020 – Feb 29 2020", "Tokyo", true},
+		{"Last week", "last week", "Mar 08 2020 – Mar 14 2020", "", true},
+		{"Last month", "Tokyo last month", "Feb 01 2020 – Feb 29 2020", "Tokyo", true},
+		{"Last year", "past year", "Jan 01 2019 – Dec 31 2019", "", true},
+		{"Number in the title", "3D printing", "", "3D printing", false},
+		{"Range not at the end", "Tokyo 7d tower", "", "Tokyo 7d tower", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dateRange, text, found := ParseDateRange(tt.text, now)
+			if found != tt.expectedFound || text != tt.expectedText || (found && dateRange.String() != tt.expectedRange) {
+				t.Errorf("ParseDateRange() = %q, %q, %v, want %q, %q, %v", dateRange, text, found, tt.expectedRange, tt.expectedText, tt.expectedFound)
+			}
+		})
+	}
+}
+
+func Test_DateRange(t *testing.T) {
+	dateRange := NewDateRange(time.Date(2020, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2020, 3, 7, 23, 0, 0, 0, time.UTC))
+	if days := dateRange.Days(); days != 7 {
+		t.Errorf("Days() = %d, want 7", days)
+	}
+	if previous := dateRange.Previous().String(); previous != "Feb 23 2020 – Feb 29 2020" {
+		t.Errorf("Previous() = %q", previous)
+	}
+	// Longer than a time.Duration can hold
+	centuries := NewDateRange(time.Date(1526, 10, 18, 0, 0, 0, 0, time.UTC), time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC))
+	if days := centuries.Days(); days != 182622 {
+		t.Errorf("Days() = %d, want 182622", days)
+	}
+	if previous := centuries.Previous().String(); previous != "Oct 17 1026 – Oct 17 1526" {
+		t.Errorf("Previous() = %q", previous)
+	}
+	if dateRange.IsWholeMonth() || !NewDateRange(time.Date(2020, 2, 1, 0, 0, 0, 0, time.UTC), time.Date(2020, 2, 29, 0, 0, 0, 0, time.UTC)).IsWholeMonth() {
+		t.Errorf("IsWholeMonth() is wrong")
+	}
+	if !dateRange.Contains(time.Date(2020, 3, 7, 12, 0, 0, 0, time.UTC)) || dateRange.Contains(time.Date(2020, 3, 8, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Contains() is wrong at the end of the range")
+	}
+}
+
+func Test_rangeSegments(t *testing.T) {
+	day := func(month time.Month, d int) time.Time { return time.Date(2020, month, d, 0, 0, 0, 0, time.UTC) }
+	tests := []struct {
+		name      string
+		dateRange DateRange
+		expected  []string
+	}{
+		{"Days", DateRange{day(3, 1), day(3, 3)}, []string{"Mar 01 2020", "Mar 02 2020", "Mar 03 2020"}},
+		{"Whole month", DateRange{day(2, 1), day(2, 29)}, []string{"Feb 01 2020 – Feb 29 2020"}},
+		{"Days around a month", DateRange{day(1, 31), day(3, 1)}, []string{"Jan 31 2020", "Feb 01 2020 – Feb 29 2020", "Mar 01 2020"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			segments := []string{}
+			for _, segment := range rangeSegments(tt.dateRange) {
+				segments = append(segments, segment.String())
+			}
+			if !reflect.DeepEqual(segments, tt.expected) {
+				t.Errorf("rangeSegments() = %q, want %q", segments, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,340 @@
+package wikipedia
+
+import (
+	"encoding/json"
+	"html"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// The longest section text returned, leaving room in a Slack
+// section block for the title and link
+const sectionExtractLimit = 2500
+
+// Section is a heading of an article
+type Section struct {
+	// Index is the position of the section in the article, as used by the API
+	Index string
+	// Level is the heading level, where 2 is a top level section
+	Level int
+	// Number is the position in the table of contents, like "2.1"
+	Number string
+	// Line is the plain text of the heading
+	Line   string
+	Anchor string
+}
+
+// SectionResult is a section of an article that was looked up by name
+type SectionResult struct {
+	// Page has the section title, text and deep link; it is the article
+	// itself if the section was not found
+	Page Page
+	// Section is nil if no section matched the requested name
+	Section *Section
+	// Sections are all the sections of the article
+	Sections []Section
+}
+
+// ParseSectionFromText splits "Title#Section" into the title and section
+// name. Parameters like lang=xx are left out of both, so "C# lang=en" is
+// the article "C#". Found is false if the text has no section.
+func ParseSectionFromText(text string) (title string, section string, found bool) {
+	stripped := StripParameters(text)
+	index := strings.LastIndex(stripped, "#")
+	if index < 0 || len(strings.TrimSpace(stripped[index+1:])) == 0 {
+		return text, "", false
+	}
+	return strings.TrimSpace(stripped[:index]), strings.TrimSpace(stripped[index+1:]), true
+}
+
+// FetchSection looks up a single section of an article from a text like
+// "Influenza#Symptoms". The article is found the same way
+// FetchGetGeneralTerm does, and the section heading is matched loosely.
+func FetchSection(term string) (result SectionResult, wiki Backend, actualTitle string, sectionName string) {
+	wiki, term = ParseWikiFromText(term)
+	actualTitle, sectionName, _ = ParseSectionFromText(term)
+	result.Page = getNotFound()[0]
+
+	mediaWiki, ok := wiki.(*MediaWiki)
+	if !ok || len(actualTitle) == 0 {
+		return result, wiki, actualTitle, sectionName
+	}
+	results, _ := getGeneralTerm(wiki, actualTitle, false)
+	if len(results) != 1 || results[0].Title == "Not found." {
+		return result, wiki, actualTitle, sectionName
+	}
+	result.Page = results[0]
+
+	result.Sections = mediaWiki.Sections(result.Page.Title)
+	section, ok := matchSection(result.Sections, sectionName)
+	if !ok {
+		return result, wiki, actualTitle, sectionName
+	}
+	result.Section = &section
+	result.Page = sectionPage(mediaWiki, result.Page, mediaWiki.PlainText(result.Page.Title), result.Sections, section)
+	return result, wiki, actualTitle, sectionName
+}
+
+// FetchOutline lists the sections of an article. The article is found
+// the same way FetchGetGeneralTerm does; the page is "Not found." if
+// there is no single matching article.
+func FetchOutline(term string) (page Page, sections []Section, wiki Backend, actualTitle string) {
+	wiki, actualTitle = ParseWikiFromText(term)
+	page = getNotFound()[0]
+	sections = []Section{}
+
+	mediaWiki, ok := wiki.(*MediaWiki)
+	if !ok || len(actualTitle) == 0 {
+		return page, sections, wiki, actualTitle
+	}
+	results, _ := getGeneralTerm(wiki, actualTitle, false)
+	if len(results) != 1 || results[0].Title == "Not found." {
+		return page, sections, wiki, actualTitle
+	}
+	page = results[0]
+	return page, mediaWiki.Sections(page.Title), wiki, actualTitle
+}
+
+// FetchSectionByIndex outputs a section of an article by its index, as
+// listed by FetchOutline. The term is the exact article title, with the
+// wiki given the same way as other requests.
+func FetchSectionByIndex(term string, index string) (page Page, wiki Backend, found bool) {
+	wiki, title := ParseWikiFromText(term)
+	mediaWiki, ok := wiki.(*MediaWiki)
+	if !ok || len(title) == 0 {
+		return getNotFound()[0], wiki, false
+	}
+
+	sections := mediaWiki.Sections(title)
+	for _, section := range sections {
+		if section.Index == index {
+			page = Page{Title: title, URL: mediaWiki.ArticleURL(title)}
+			return sectionPage(mediaWiki, page, mediaWiki.PlainText(title), sections, section), wiki, true
+		}
+	}
+	return getNotFound()[0], wiki, false
+}
+
+// SectionURL is the deep link to a section of an article
+func (w *MediaWiki) SectionURL(title string, section Section) string {
+	return w.ArticleURL(title) + "#" + url.PathEscape(section.Anchor)
+}
+
+// Output the page of an article narrowed down to one of its sections,
+// with the section's text, from the plain text of the article, and a deep
+// link to it. The HTML extract of the summary is dropped, since it is the
+// lead of the article rather than the section.
+func sectionPage(wiki *MediaWiki, page Page, plainText string, sections []Section, section Section) Page {
+	text := sectionText(plainText, sections, section)
+	page.URL = wiki.SectionURL(page.Title, section)
+	page.Title += " § " + section.Line
+	page.Extract = truncateAtWord(text, sectionExtractLimit)
+	page.ExtractHTML = ""
+	if len(page.Extract) < len(text) {
+		page.Extract += " [...]"
+	}
+	return page
+}
+
+// Sections lists the headings of an article, in order
+func (w *MediaWiki) Sections(title string) []Section {
+	params := url.Values{}
+
+	params.Add("action", "parse")
+	params.Add("format", "json")
+	params.Add("prop", "sections")
+	params.Add("redirects", "1")
+	params.Add("page", strings.TrimSpace(title))
+
+	url := w.actionAPIURL(params)
+	toLog("Sections", "URL: "+url)
+
+	body, readErr := fetchFromAPI(url)
+	if readErr != nil {
+		return []Section{}
+	}
+	return processParseSections(body)
+}
+
+// PlainText is the full plain text of an article, with the headings
+// marked up like "== History =="
+func (w *MediaWiki) PlainText(title string) string {
+	params := url.Values{}
+
+	params.Add("action", "query")
+	params.Add("format", "json")
+	params.Add("prop", "extracts")
+	params.Add("explaintext", "1")
+	params.Add("exsectionformat", "wiki")
+	params.Add("redirects", "1")
+	params.Add("titles", strings.TrimSpace(title))
+
+	url := w.actionAPIURL(params)
+	toLog("PlainText", "URL: "+url)
+
+	body, readErr := fetchFromAPI(url)
+	if readErr != nil {
+		return ""
+	}
+
+	record := ActionAPIGeneratorResponse{}
+	if jsonErr := json.Unmarshal(body, &record); jsonErr != nil {
+		return ""
+	}
+	for _, page := range record.Query.Pages {
+		return page.Extract
+	}
+	return ""
+}
+
+// Output the sections of an action=parse response
+func processParseSections(body []byte) (sections []Section) {
+	record := ActionAPIParseSectionsResponse{}
+	sections = []Section{}
+	if jsonErr := json.Unmarshal(body, &record); jsonErr != nil {
+		return sections
+	}
+	if len(record.Error.Code) != 0 {
+		toLog("processParseSections", "API error: "+record.Error.Info)
+		return sections
+	}
+
+	for _, section := range record.Parse.Sections {
+		level, _ := strconv.Atoi(section.Level)
+		sections = append(sections, Section{
+			Index:  section.Index,
+			Level:  level,
+			Number: section.Number,
+			// Headings may have some markup, like italics
+			Line:   strings.TrimSpace(html.UnescapeString(tagRegexp.ReplaceAllString(section.Line, ""))),
+			Anchor: section.Anchor,
+		})
+	}
+	return sections
+}
+
+// Find the section best matching a name: an exact match, then a heading
+// starting with or containing the name, then the closest spelling
+func matchSection(sections []Section, name string) (section Section, found bool) {
+	name = normalizeHeading(name)
+	if len(name) == 0 {
+		return section, false
+	}
+
+	matchers := []func(heading string) bool{
+		func(heading string) bool { return heading == name },
+		func(heading string) bool { return strings.HasPrefix(heading, name) },
+		func(heading string) bool { return strings.Contains(heading, name) },
+	}
+	for _, matches := range matchers {
+		for _, candidate := range sections {
+			if matches(normalizeHeading(candidate.Line)) {
+				return candidate, true
+			}
+		}
+	}
+
+	// Allow a typo or two, depending on the length of the name
+	best := len(name)/4 + 1
+	for _, candidate := range sections {
+		if distance := editDistance(normalizeHeading(candidate.Line), name); distance < best {
+			best = distance
+			section, found = candidate, true
+		}
+	}
+	return section, found
+}
+
+// Normalize a heading for matching: lowercase letters and digits
+// separated by single spaces
+func normalizeHeading(heading string) string {
+	words := strings.FieldsFunc(strings.ToLower(heading), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return strings.Join(words, " ")
+}
+
+// Output the Levenshtein distance between two strings, in runes
+func editDistance(a string, b string) int {
+	first, second := []rune(a), []rune(b)
+	previous := make([]int, len(second)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(first); i++ {
+		current := make([]int, len(second)+1)
+		current[0] = i
+		for j := 1; j <= len(second); j++ {
+			cost := 1
+			if first[i-1] == second[j-1] {
+				cost = 0
+			}
+			current[j] = minInt(minInt(previous[j]+1, current[j-1]+1), previous[j-1]+cost)
+		}
+		previous = current
+	}
+	return previous[len(second)]
+}
+
+// Output the smaller of two numbers
+func minInt(a int, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+var plainHeadingRegexp = regexp.MustCompile(`(?m)^(={2,6})\s*(.*?)\s*={2,6}\s*$`)
+
+// Cut the text of a section, with its subsections, out of the plain text
+// of an article. Headings with the same name are told apart by their order.
+func sectionText(plainText string, sections []Section, section Section) string {
+	occurrence := 0
+	for _, candidate := range sections {
+		if candidate.Index == section.Index {
+			break
+		}
+		if candidate.Line == section.Line {
+			occurrence++
+		}
+	}
+
+	headings := plainHeadingRegexp.FindAllStringSubmatchIndex(plainText, -1)
+	start := -1
+	for index, heading := range headings {
+		level := heading[3] - heading[2]
+		if start < 0 {
+			if plainText[heading[4]:heading[5]] != section.Line {
+				continue
+			}
+			if occurrence > 0 {
+				occurrence--
+				continue
+			}
+			start = index
+			continue
+		}
+		if level <= headings[start][3]-headings[start][2] {
+			return cleanSectionText(plainText[headings[start][1]:heading[0]])
+		}
+	}
+	if start < 0 {
+		return ""
+	}
+	return cleanSectionText(plainText[headings[start][1]:])
+}
+
+// Turn the subsection headings into plain lines, and drop empty lines
+func cleanSectionText(text string) string {
+	text = plainHeadingRegexp.ReplaceAllString(text, "$2:")
+	lines := []string{}
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); len(line) != 0 {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,106 @@
+package wikipedia
+
+import (
+	"reflect"
+	"testing"
+)
+
+var testSections = []Section{
+	{"1", 2, "1", "History", "History"},
+	{"2", 3, "1.1", "Early history", "Early_history"},
+	{"3", 2, "2", "Signs and symptoms", "Signs_and_symptoms"},
+	{"4", 3, "2.1", "History", "History_2"},
+	{"5", 2, "3", "Treatment", "Treatment"},
+}
+
+func Test_ParseSectionFromText(t *testing.T) {
+	tests := []struct {
+		name            string
+		text            string
+		expectedTitle   string
+		expectedSection string
+		expectedFound   bool
+	}{
+		{"Title only", "Influenza", "Influenza", "", false},
+		{"Title and section", "Influenza#Symptoms", "Influenza", "Symptoms", true},
+		{"Spaces around the fragment", "Influenza # Signs and symptoms ", "Influenza", "Signs and symptoms", true},
+		{"Empty fragment", "C#", "C#", "", false},
+		{"Empty fragment and parameters", "C# lang=en", "C# lang=en", "", false},
+		{"Section and parameters", "Influenza#Symptoms lang=fr fallback=en", "Influenza", "Symptoms", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			title, section, found := ParseSectionFromText(tt.text)
+			if title != tt.expectedTitle || section != tt.expectedSection || found != tt.expectedFound {
+				t.Errorf("ParseSectionFromText() = %q, %q, %v, want %q, %q, %v", title, section, found, tt.expectedTitle, tt.expectedSection, tt.expectedFound)
+			}
+		})
+	}
+}
+
+func Test_processParseSections(t *testing.T) {
+	body := []byte(`{"parse":{"title":"Influenza","pageid":15069,"sections":[{"toclevel":1,"level":"2","line":"<i>Signs</i> &amp; symptoms","number":"1","index":"1","fromtitle":"Influenza","byteoffset":100,"anchor":"Signs_&amp;_symptoms"}]}}`)
+	expected := []Section{{"1", 2, "1", "Signs & symptoms", "Signs_&amp;_symptoms"}}
+	if sections := processParseSections(body); !reflect.DeepEqual(sections, expected) {
+		t.Errorf("processParseSections() = %v\nExpected:\n %v", sections, expected)
+	}
+
+	errorBody := []byte(`{"error":{"code":"missingtitle","info":"The page you specified doesn't exist."}}`)
+	if sections := processParseSections(errorBody); len(sections) != 0 {
+		t.Errorf("processParseSections() with an error = %v, want no sections", sections)
+	}
+}
+
+func Test_matchSection(t *testing.T) {
+	tests := []struct {
+		name          string
+		query         string
+		expectedIndex string
+		expectedFound bool
+	}{
+		{"Exact match", "treatment", "5", true},
+		{"First of duplicate headings", "History", "1", true},
+		{"Prefix", "signs", "3", true},
+		{"Contained", "symptoms", "3", true},
+		{"Typo", "Tretment", "5", true},
+		{"No match", "Epidemiology", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			section, found := matchSection(testSections, tt.query)
+			if section.Index != tt.expectedIndex || found != tt.expectedFound {
+				t.Errorf("matchSection() = %q, %v, want %q, %v", section.Index, found, tt.expectedIndex, tt.expectedFound)
+			}
+		})
+	}
+}
+
+func Test_sectionText(t *testing.T) {
+	plainText := "Lead text.\n\n\n== History ==\nFirst.\n\n\n=== Early history ===\nEarly.\n\n\n== Signs and symptoms ==\nFever.\n\n\n=== History ===\nOld cases.\n\n\n== Treatment ==\nRest."
+	tests := []struct {
+		name     string
+		section  Section
+		expected string
+	}{
+		{"With a subsection", testSections[0], "First.\nEarly history:\nEarly."},
+		{"Duplicate heading", testSections[3], "Old cases."},
+		{"Last section", testSections[4], "Rest."},
+		{"Missing heading", Section{"9", 2, "9", "Epidemiology", "Epidemiology"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if text := sectionText(plainText, testSections, tt.section); text != tt.expected {
+				t.Errorf("sectionText() = %q, want %q", text, tt.expected)
+			}
+		})
+	}
+}
+
+func Test_sectionPage(t *testing.T) {
+	plainText := "Lead text.\n\n\n== History ==\nFirst.\n\n\n=== Early history ===\nEarly.\n\n\n== Signs and symptoms ==\nFever."
+	page := Page{Title: "Malaria", URL: "https://en.wikipedia.org/wiki/Malaria", Extract: "Lead text.", ExtractHTML: "<p>lead</p>"}
+	expected := Page{Title: "Malaria § Signs and symptoms", URL: "https://en.wikipedia.org/wiki/Malaria#Signs_and_symptoms", Extract: "Fever."}
+	if sectioned := sectionPage(Wikipedia("en"), page, plainText, testSections, testSections[2]); !reflect.DeepEqual(sectioned, expected) {
+		t.Errorf("sectionPage() = %+v, want %+v", sectioned, expected)
+	}
+}
@@ -0,0 +1,130 @@
+package wikipedia
+
+import (
+	"encoding/json"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// How long the site information of a wiki is remembered
+const siteInfoTTL = 24 * time.Hour
+
+// The canonical names of the namespaces every MediaWiki has, used when
+// a wiki's own names can't be fetched
+var defaultNamespaces = []string{
+	"Media", "Special", "Talk", "User", "User talk", "Project", "Project talk",
+	"File", "File talk", "Image", "MediaWiki", "MediaWiki talk", "Template",
+	"Template talk", "Help", "Help talk", "Category", "Category talk",
+	"Wikipedia", "Wikipedia talk", "Portal", "Portal talk", "Draft",
+	"Draft talk", "Module", "Module talk", "TimedText", "TimedText talk",
+}
+
+// SiteInfo is the information about a wiki that tells articles apart
+// from other pages
+type SiteInfo struct {
+	// MainPage is the title of the wiki's main page
+	MainPage string
+	// Namespaces are the lowercase names and aliases of all namespaces
+	// other than the one articles are in
+	Namespaces map[string]bool
+	fetched    time.Time
+}
+
+// Remembered site information, by Action API endpoint
+var siteInfos = map[string]SiteInfo{}
+var siteInfosMutex sync.Mutex
+
+// SiteInfo fetches the main page and the namespace names of the wiki. The
+// answer is remembered for a day. If it can't be fetched, the English
+// names are used.
+func (w *MediaWiki) SiteInfo() SiteInfo {
+	key := w.actionAPIURL(url.Values{})
+	siteInfosMutex.Lock()
+	cached, ok := siteInfos[key]
+	siteInfosMutex.Unlock()
+	if ok && time.Since(cached.fetched) < siteInfoTTL {
+		return cached
+	}
+
+	params := url.Values{}
+
+	params.Add("action", "query")
+	params.Add("format", "json")
+	params.Add("formatversion", "2")
+	params.Add("meta", "siteinfo")
+	params.Add("siprop", "general|namespaces|namespacealiases")
+
+	url := w.actionAPIURL(params)
+	toLog("SiteInfo", "URL: "+url)
+
+	body, readErr := fetchFromAPI(url)
+	if readErr != nil {
+		return defaultSiteInfo()
+	}
+	info, found := processSiteInfo(body)
+	if !found {
+		return defaultSiteInfo()
+	}
+
+	siteInfosMutex.Lock()
+	siteInfos[key] = info
+	siteInfosMutex.Unlock()
+	return info
+}
+
+// IsArticle checks whether a title is an article rather than the main
+// page or a page in another namespace, like "Special:Search"
+func (info SiteInfo) IsArticle(title string) bool {
+	title = strings.TrimSpace(strings.ReplaceAll(title, "_", " "))
+	if strings.EqualFold(title, info.MainPage) {
+		return false
+	}
+	if colon := strings.Index(title, ":"); colon > 0 {
+		return !info.Namespaces[strings.ToLower(strings.TrimSpace(title[:colon]))]
+	}
+	return true
+}
+
+// Output the site information in an action API response
+func processSiteInfo(body []byte) (info SiteInfo, found bool) {
+	record := ActionAPISiteInfoResponse{}
+	if jsonErr := json.Unmarshal(body, &record); jsonErr != nil || len(record.Query.Namespaces) == 0 {
+		if len(record.Error.Info) != 0 {
+			toLog("processSiteInfo", "Error fetching. Details: "+record.Error.Info)
+		}
+		return info, false
+	}
+
+	info = SiteInfo{
+		MainPage:   record.Query.General.Mainpage,
+		Namespaces: map[string]bool{},
+		fetched:    time.Now(),
+	}
+	for _, namespace := range record.Query.Namespaces {
+		if namespace.ID == 0 {
+			continue
+		}
+		for _, name := range []string{namespace.Name, namespace.Canonical} {
+			if len(name) != 0 {
+				info.Namespaces[strings.ToLower(name)] = true
+			}
+		}
+	}
+	for _, alias := range record.Query.Namespacealiases {
+		if alias.ID != 0 {
+			info.Namespaces[strings.ToLower(alias.Alias)] = true
+		}
+	}
+	return info, true
+}
+
+// Output the site information of an English wiki, without fetching it
+func defaultSiteInfo() SiteInfo {
+	info := SiteInfo{MainPage: "Main Page", Namespaces: map[string]bool{}}
+	for _, name := range defaultNamespaces {
+		info.Namespaces[strings.ToLower(name)] = true
+	}
+	return info
+}
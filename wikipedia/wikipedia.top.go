@@ -0,0 +1,318 @@
+package wikipedia
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var wikiAnalyticsMonthlyPageviewsEndpoint = "https://wikimedia.org/api/rest_v1/metrics/pageviews/top/%s/%s/%d/%02d/all-days"       // "all-access/2020/06/all-days"
+var wikiAnalyticsCountryPageviewsEndpoint = "https://wikimedia.org/api/rest_v1/metrics/pageviews/top-per-country/%s/%s/%d/%02d/%s" // "DE/all-access/2020/06/02"
+
+// How long fetched top lists are remembered. Lists of past days don't
+// change, and are asked for again by ranges and trends.
+const topTotalsTTL = 6 * time.Hour
+
+// topTotals are the views of the articles of a fetched top list
+type topTotals struct {
+	totals  map[string]int
+	fetched time.Time
+}
+
+// Remembered top lists, by URL
+var topTotalsCache = map[string]topTotals{}
+var topTotalsMutex sync.Mutex
+
+// Options like "country=DE"
+var topCountryRegexp = regexp.MustCompile(`(?i)(?:^|\s)country=([a-z]{2})(?:\s|$)`)
+
+// Options like "access=mobile-web"
+var topAccessRegexp = regexp.MustCompile(`(?i)(?:^|\s)access=(\S+)`)
+
+// TopPageviewsOptions narrow the top lists down to the views from one
+// access method or one country
+type TopPageviewsOptions struct {
+	// Access is one of PageviewsAccess, or empty for all-access
+	Access string
+	// Country is a two letter ISO 3166 code, like "DE", or empty for
+	// all countries
+	Country string
+}
+
+// How many days or months of top pageviews are fetched at the same time
+const topPageviewsConcurrency = 6
+
+// The length of the top lists of the analytics API
+const topPageviewsLimit = 1000
+
+// TopRangeMaxDays is the longest range of days TopPageviewsRange adds up,
+// since each of its days or months is a request of its own
+const TopRangeMaxDays = 366
+
+// DefaultTopDenylist are pages left out of top lists on all wikis, since
+// their views come from broken links and scripts rather than readers
+var DefaultTopDenylist = []string{"-", "Undefined"}
+
+// FetchTopPageviewsRange fetches the top articles by pageview over a range
+// of days. Lang parameter will dictate the Wikipedia that will be searched.
+// If given empty string, will fall back on "en"
+func FetchTopPageviewsRange(dateRange DateRange, lang string) (resp []PagelistPage) {
+	resp, _ = Wikipedia(lang).TopPageviewsRange(dateRange, TopPageviewsOptions{})
+	return resp
+}
+
+// ParseTopPageviewsOptions looks for the country=XX and access=method
+// expressions and outputs the options with the rest of the text. Unknown
+// access methods are ignored.
+func ParseTopPageviewsOptions(text string) (options TopPageviewsOptions, remainingText string) {
+	remainingText = text
+	if match := topCountryRegexp.FindStringSubmatch(remainingText); match != nil {
+		options.Country = strings.ToUpper(match[1])
+		remainingText = strings.Replace(remainingText, strings.TrimSpace(match[0]), "", 1)
+	}
+	if match := topAccessRegexp.FindStringSubmatch(remainingText); match != nil {
+		if access := pickOption(strings.ToLower(match[1]), PageviewsAccess, ""); access != PageviewsAccess[0] {
+			options.Access = access
+		}
+		remainingText = strings.Replace(remainingText, strings.TrimSpace(match[0]), "", 1)
+	}
+	return options, strings.Join(strings.Fields(remainingText), " ")
+}
+
+// Labels outputs readable names of the options that narrow the list
+// down, like "mobile web" and "country: DE"
+func (o TopPageviewsOptions) Labels() (labels []string) {
+	if len(o.Access) != 0 {
+		labels = append(labels, strings.ReplaceAll(o.Access, "-", " "))
+	}
+	if len(o.Country) != 0 {
+		labels = append(labels, "country: "+o.Country)
+	}
+	return labels
+}
+
+// Output the access method in the analytics API, all-access by default
+func (o TopPageviewsOptions) access() string {
+	if len(o.Access) == 0 {
+		return PageviewsAccess[0]
+	}
+	return o.Access
+}
+
+// TopPageviewsRange fetches the top articles by pageview over a range of
+// days, narrowed down by the options. Whole calendar months are fetched as
+// a month, and the other days one by one, and their views are added up.
+// Since each list only has the top 1000 articles, the totals of articles
+// near the end of the lists are lower than their actual views. Ranges
+// longer than TopRangeMaxDays are cut down to their latest days.
+//
+// Missing are the days and months whose lists couldn't be fetched, which
+// aren't in the totals.
+func (w *MediaWiki) TopPageviewsRange(dateRange DateRange, options TopPageviewsOptions) (list []PagelistPage, missing []DateRange) {
+	if !w.Supports(FeaturePageviews) {
+		return []PagelistPage{{"Not found.", "", 0, ""}}, missing
+	}
+	if dateRange.Days() > TopRangeMaxDays {
+		toLog("TopPageviewsRange", "Range cut down to the last "+strconv.Itoa(TopRangeMaxDays)+" days: "+dateRange.String())
+		dateRange.Start = dateRange.End.AddDate(0, 0, 1-TopRangeMaxDays)
+	}
+	if dateRange.Days() == 1 {
+		return w.TopPageviews(dateRange.Start, options), missing
+	}
+
+	type answer struct {
+		segment DateRange
+		totals  map[string]int
+	}
+	segments := rangeSegments(dateRange)
+	answers := make(chan answer, len(segments))
+	slots := make(chan bool, topPageviewsConcurrency)
+	for _, segment := range segments {
+		go func(segment DateRange) {
+			slots <- true
+			defer func() { <-slots }()
+			answers <- answer{segment, w.topPageviewsTotals(segment, options)}
+		}(segment)
+	}
+
+	totals := map[string]int{}
+	for range segments {
+		a := <-answers
+		if a.totals == nil {
+			missing = append(missing, a.segment)
+		}
+		for article, views := range a.totals {
+			totals[article] += views
+		}
+	}
+	sort.Slice(missing, func(i, j int) bool {
+		return missing[i].Start.Before(missing[j].Start)
+	})
+	toLog("TopPageviewsRange", fmt.Sprintf("%d of %d days or months answered", len(segments)-len(missing), len(segments)))
+	return rankTopPageviews(totals, w), missing
+}
+
+// Fetch the views of the top articles of a day or a whole month. The
+// output is nil if the list couldn't be fetched.
+func (w *MediaWiki) topPageviewsTotals(segment DateRange, options TopPageviewsOptions) map[string]int {
+	t := segment.Start
+	day := fmt.Sprintf("%02d", t.Day())
+	if segment.IsWholeMonth() {
+		day = "all-days"
+	}
+	url := fmt.Sprintf(wikiAnalyticsCountryPageviewsEndpoint, options.Country, options.access(), t.Year(), int(t.Month()), day)
+	if len(options.Country) == 0 {
+		url = fmt.Sprintf(wikiAnalyticsPageviewsEndpoint, w.PageviewsProject, options.access(), t.Year(), int(t.Month()), t.Day())
+		if segment.IsWholeMonth() {
+			url = fmt.Sprintf(wikiAnalyticsMonthlyPageviewsEndpoint, w.PageviewsProject, options.access(), t.Year(), int(t.Month()))
+		}
+	}
+	if cached := cachedTopTotals(url); cached != nil {
+		return cached
+	}
+	toLog("TopPageviewsRange", "URL: "+url)
+
+	body, readErr := fetchFromAPI(url)
+	if readErr != nil {
+		return nil
+	}
+	totals := processAnalyticsTopTotals(body)
+	if len(options.Country) != 0 {
+		totals = processAnalyticsCountryTotals(body, w.PageviewsProject)
+	}
+	cacheTopTotals(url, totals)
+	return totals
+}
+
+// Output the remembered totals of a top list, or nil
+func cachedTopTotals(url string) map[string]int {
+	topTotalsMutex.Lock()
+	defer topTotalsMutex.Unlock()
+	if cached, ok := topTotalsCache[url]; ok && time.Since(cached.fetched) < topTotalsTTL {
+		return cached.totals
+	}
+	return nil
+}
+
+// Remember the totals of a top list, and forget the ones that expired
+func cacheTopTotals(url string, totals map[string]int) {
+	if totals == nil {
+		return
+	}
+	topTotalsMutex.Lock()
+	defer topTotalsMutex.Unlock()
+	for key, cached := range topTotalsCache {
+		if time.Since(cached.fetched) >= topTotalsTTL {
+			delete(topTotalsCache, key)
+		}
+	}
+	topTotalsCache[url] = topTotals{totals, time.Now()}
+}
+
+// Process the result from the Wikipedia analytics top pageviews endpoint
+// and return the views of each article in it, or nil if there is no list
+func processAnalyticsTopTotals(body []byte) map[string]int {
+	record := AnalyticsPageviews{}
+	jsonErr := json.Unmarshal(body, &record)
+	if jsonErr != nil || len(record.Items) == 0 {
+		if len(record.Detail) != 0 {
+			toLog("processAnalyticsTopTotals", "Error fetching. Details: "+record.Detail)
+		}
+		return nil
+	}
+	totals := map[string]int{}
+	for _, page := range record.Items[0].Articles {
+		totals[page.Article] += page.Views
+	}
+	return totals
+}
+
+// Process the result from the Wikipedia analytics top per country endpoint
+// and return the views of each article of the given project in it, or nil
+// if there is no list
+func processAnalyticsCountryTotals(body []byte, project string) map[string]int {
+	record := AnalyticsTopPerCountry{}
+	jsonErr := json.Unmarshal(body, &record)
+	if jsonErr != nil || len(record.Items) == 0 {
+		if len(record.Detail) != 0 {
+			toLog("processAnalyticsCountryTotals", "Error fetching. Details: "+record.Detail)
+		}
+		return nil
+	}
+	totals := map[string]int{}
+	for _, page := range record.Items[0].Articles {
+		if page.Project == project {
+			totals[page.Article] += page.ViewsCeil
+		}
+	}
+	return totals
+}
+
+// Output the most viewed articles, in the same form as the top list of a
+// single day
+func rankTopPageviews(totals map[string]int, wiki *MediaWiki) (list []PagelistPage) {
+	if len(totals) == 0 {
+		return []PagelistPage{{"Not found.", "", 0, ""}}
+	}
+	articles := []string{}
+	for article := range totals {
+		articles = append(articles, article)
+	}
+	sort.Slice(articles, func(i, j int) bool {
+		return totals[articles[i]] > totals[articles[j]] || (totals[articles[i]] == totals[articles[j]] && articles[i] < articles[j])
+	})
+	if len(articles) > topPageviewsLimit {
+		articles = articles[:topPageviewsLimit]
+	}
+	for index, article := range articles {
+		list = append(list, PagelistPage{
+			strings.ReplaceAll(article, "_", " "), // Title
+			wiki.ArticleURL(article),              // URL
+			index + 1,                             // Rank
+			strconv.Itoa(totals[article])})        // Pageviews, stringified
+	}
+	return list
+}
+
+// FilterTopPageviews leaves the main page, pages outside of the article
+// namespace, like "Special:Search", and the titles on the denylist out of a
+// top list. The remaining articles are ranked again, up to the limit.
+// Titles on the denylist match regardless of case and underscores.
+func FilterTopPageviews(list []PagelistPage, wiki *MediaWiki, denylist []string, limit int) (filtered []PagelistPage) {
+	if len(list) == 0 || list[0].Title == "Not found." {
+		return list
+	}
+	isListed := topListFilter(wiki, denylist)
+	for _, page := range list {
+		if len(filtered) == limit {
+			break
+		}
+		if !isListed(page.Title) {
+			continue
+		}
+		page.Rank = len(filtered) + 1
+		filtered = append(filtered, page)
+	}
+	if len(filtered) == 0 {
+		return []PagelistPage{{"Not found.", "", 0, ""}}
+	}
+	return filtered
+}
+
+// Output a check of whether a title belongs in a top list, which only
+// articles that aren't on the denylist do
+func topListFilter(wiki *MediaWiki, denylist []string) func(title string) bool {
+	denied := map[string]bool{}
+	for _, title := range append(DefaultTopDenylist, denylist...) {
+		denied[strings.ToLower(strings.ReplaceAll(title, "_", " "))] = true
+	}
+	info := wiki.SiteInfo()
+	return func(title string) bool {
+		return info.IsArticle(title) && !denied[strings.ToLower(strings.ReplaceAll(title, "_", " "))]
+	}
+}
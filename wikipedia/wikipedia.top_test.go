@@ -0,0 +1,119 @@
+package wikipedia
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func Test_rankTopPageviews(t *testing.T) {
+	totals := map[string]int{}
+	for _, body := range []string{
+		`{"items":[{"project":"en.wikipedia","access":"all-access","year":"2020","month":"03","day":"01","articles":[{"article":"Main_Page","views":5000,"rank":1},{"article":"Tokyo","views":300,"rank":2}]}]}`,
+		`{"items":[{"project":"en.wikipedia","access":"all-access","year":"2020","month":"03","day":"all-days","articles":[{"article":"Tokyo","views":4000,"rank":1},{"article":"Osaka","views":300,"rank":2}]}]}`,
+		`{"title":"Not found.","detail":"The date(s) you used are valid, but we either do not have data for those date(s), or the project you asked for is not loaded yet."}`,
+	} {
+		for article, views := range processAnalyticsTopTotals([]byte(body)) {
+			totals[article] += views
+		}
+	}
+	expected := []PagelistPage{
+		{"Main Page", "https://en.wikipedia.org/wiki/Main_Page", 1, "5000"},
+		{"Tokyo", "https://en.wikipedia.org/wiki/Tokyo", 2, "4300"},
+		{"Osaka", "https://en.wikipedia.org/wiki/Osaka", 3, "300"},
+	}
+	if list := rankTopPageviews(totals, Wikipedia("en")); !reflect.DeepEqual(list, expected) {
+		t.Errorf("rankTopPageviews() = %v, want %v", list, expected)
+	}
+}
+
+func Test_SiteInfoIsArticle(t *testing.T) {
+	info := defaultSiteInfo()
+	for title, expected := range map[string]bool{
+		"Tokyo":              true,
+		"Main Page":          false,
+		"Special:Search":     false,
+		"File:Example.jpg":   false,
+		"Wikipedia:Sandbox":  false,
+		"Star Wars: Andor":   true,
+		"Mission:Impossible": true,
+	} {
+		if isArticle := info.IsArticle(title); isArticle != expected {
+			t.Errorf("IsArticle(%q) = %v, want %v", title, isArticle, expected)
+		}
+	}
+}
+
+func Test_processSiteInfo(t *testing.T) {
+	body := []byte(`{"batchcomplete":true,"query":{"general":{"mainpage":"Wikipedia:Hauptseite","lang":"de"},"namespaces":{"-1":{"id":-1,"name":"Spezial","canonical":"Special"},"0":{"id":0,"name":"","content":true},"6":{"id":6,"name":"Datei","canonical":"File"}},"namespacealiases":[{"id":6,"alias":"Bild"}]}}`)
+	info, found := processSiteInfo(body)
+	if !found || info.MainPage != "Wikipedia:Hauptseite" {
+		t.Fatalf("processSiteInfo() = %+v, %v", info, found)
+	}
+	for _, title := range []string{"Spezial:Suche", "Special:Search", "Bild:Beispiel.jpg", "Wikipedia:Hauptseite"} {
+		if info.IsArticle(title) {
+			t.Errorf("IsArticle(%q) = true", title)
+		}
+	}
+	if !info.IsArticle("Berlin") {
+		t.Errorf("IsArticle(\"Berlin\") = false")
+	}
+}
+
+func Test_FilterTopPageviews(t *testing.T) {
+	wiki := Wikipedia("en")
+	// Known site information, so nothing is fetched
+	info := defaultSiteInfo()
+	info.fetched = time.Now()
+	siteInfos[wiki.actionAPIURL(url.Values{})] = info
+
+	list := []PagelistPage{
+		{"Main Page", "", 1, "9000"},
+		{"Special:Search", "", 2, "8000"},
+		{"Tokyo", "", 3, "700"},
+		{"-", "", 4, "600"},
+		{"Bot Magnet", "", 5, "500"},
+		{"Osaka", "", 6, "400"},
+		{"Kyoto", "", 7, "300"},
+	}
+	expected := []PagelistPage{
+		{"Tokyo", "", 1, "700"},
+		{"Osaka", "", 2, "400"},
+	}
+	if filtered := FilterTopPageviews(list, wiki, []string{"bot_magnet"}, 2); !reflect.DeepEqual(filtered, expected) {
+		t.Errorf("FilterTopPageviews() = %v, want %v", filtered, expected)
+	}
+}
+
+func Test_ParseTopPageviewsOptions(t *testing.T) {
+	tests := []struct {
+		name            string
+		text            string
+		expectedOptions TopPageviewsOptions
+		expectedText    string
+	}{
+		{"No options", "last week", TopPageviewsOptions{}, "last week"},
+		{"Country", "country=de March 2020", TopPageviewsOptions{Country: "DE"}, "March 2020"},
+		{"Access and country", "2020-03-01 access=mobile-web country=FR", TopPageviewsOptions{Access: "mobile-web", Country: "FR"}, "2020-03-01"},
+		{"Default access", "access=all yesterday", TopPageviewsOptions{}, "yesterday"},
+		{"Unknown access", "access=phone", TopPageviewsOptions{}, ""},
+		{"Country code too long", "country=DEU", TopPageviewsOptions{}, "country=DEU"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options, text := ParseTopPageviewsOptions(tt.text)
+			if options != tt.expectedOptions || text != tt.expectedText {
+				t.Errorf("ParseTopPageviewsOptions() = %+v, %q, want %+v, %q", options, text, tt.expectedOptions, tt.expectedText)
+			}
+		})
+	}
+}
+
+func Test_processAnalyticsCountryTotals(t *testing.T) {
+	body := []byte(`{"items":[{"country":"DE","access":"all-access","year":"2020","month":"03","day":"01","articles":[{"article":"Hauptseite","project":"de.wikipedia","views_ceil":900000,"rank":1},{"article":"Main_Page","project":"en.wikipedia","views_ceil":100000,"rank":2},{"article":"Berlin","project":"de.wikipedia","views_ceil":20000,"rank":3}]}]}`)
+	expected := map[string]int{"Hauptseite": 900000, "Berlin": 20000}
+	if totals := processAnalyticsCountryTotals(body, "de.wikipedia"); !reflect.DeepEqual(totals, expected) {
+		t.Errorf("processAnalyticsCountryTotals() = %v, want %v", totals, expected)
+	}
+}
@@ -0,0 +1,172 @@
+package wikipedia
+
+import (
+	"encoding/json"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// The number of days before the requested day that its views are compared to
+const trendingBaselineDays = 7
+
+// How many times its usual views an article needs to be trending
+const trendingMinIncrease = 2.0
+
+// Pages in the top lists of all the days before need a bigger rise to
+// be trending, so the evergreen ones stay out
+const trendingEvergreenIncrease = 4.0
+
+// TrendingPage is an article that was viewed much more than usual
+type TrendingPage struct {
+	Title       string
+	URL         string
+	Description string
+	Rank        int
+	// Views are the views on the requested day
+	Views int
+	// Baseline is the average daily views over the days before
+	Baseline int
+	// Increase is how many times the baseline the views are
+	Increase float64
+}
+
+// Trending fetches the articles viewed much more on the given day than on
+// average over the week before, with the biggest rise first. The lists of
+// all days are fetched at the same time and remembered. Pages that aren't
+// articles, those on the denylist and evergreen pages are left out, as
+// with FilterTopPageviews.
+func (w *MediaWiki) Trending(day time.Time, denylist []string, limit int) (pages []TrendingPage, found bool) {
+	if !w.Supports(FeaturePageviews) {
+		return pages, false
+	}
+
+	day = utcDay(day)
+	lists := make([]map[string]int, trendingBaselineDays+1)
+	done := make(chan bool, len(lists))
+	for index := range lists {
+		go func(index int) {
+			date := day.AddDate(0, 0, -index)
+			lists[index] = w.topPageviewsTotals(DateRange{date, date}, TopPageviewsOptions{})
+			done <- true
+		}(index)
+	}
+	for range lists {
+		<-done
+	}
+	if lists[0] == nil {
+		return pages, false
+	}
+
+	isListed := topListFilter(w, denylist)
+	for _, page := range rankTrending(lists[0], lists[1:]) {
+		if len(pages) == limit {
+			break
+		}
+		if !isListed(page.Title) {
+			continue
+		}
+		page.URL = w.ArticleURL(page.Title)
+		page.Title = strings.ReplaceAll(page.Title, "_", " ")
+		page.Rank = len(pages) + 1
+		pages = append(pages, page)
+	}
+
+	titles := []string{}
+	for _, page := range pages {
+		titles = append(titles, page.Title)
+	}
+	descriptions := w.Descriptions(titles)
+	for index := range pages {
+		pages[index].Description = descriptions[pages[index].Title]
+	}
+	return pages, len(pages) != 0
+}
+
+// Descriptions fetches the short descriptions of the given pages, by title
+func (w *MediaWiki) Descriptions(titles []string) (descriptions map[string]string) {
+	descriptions = map[string]string{}
+	if len(titles) == 0 {
+		return descriptions
+	}
+	params := url.Values{}
+
+	params.Add("action", "query")
+	params.Add("format", "json")
+	params.Add("prop", "description")
+	params.Add("titles", strings.Join(titles, "|"))
+
+	url := w.actionAPIURL(params)
+	toLog("Descriptions", "URL: "+url)
+
+	body, readErr := fetchFromAPI(url)
+	if readErr != nil {
+		return descriptions
+	}
+	record := ActionAPIGeneratorResponse{}
+	if jsonErr := json.Unmarshal(body, &record); jsonErr != nil {
+		return descriptions
+	}
+	for _, page := range record.Query.Pages {
+		if len(page.Description) != 0 {
+			descriptions[page.Title] = page.Description
+		}
+	}
+	return descriptions
+}
+
+// Output the articles of the day's top list that rose the most compared to
+// their average over the baseline lists. An article missing from a list had
+// at most the views of that list's last article, which is used instead, so
+// new articles aren't rated infinitely high. Lists that couldn't be fetched
+// are skipped.
+func rankTrending(day map[string]int, baseline []map[string]int) (pages []TrendingPage) {
+	lists := []map[string]int{}
+	floors := []int{}
+	for _, list := range baseline {
+		if list == nil {
+			continue
+		}
+		floor := 0
+		for _, views := range list {
+			if floor == 0 || views < floor {
+				floor = views
+			}
+		}
+		lists = append(lists, list)
+		floors = append(floors, floor)
+	}
+	if len(lists) == 0 {
+		return pages
+	}
+
+	for article, views := range day {
+		total, listed := 0, 0
+		for index, list := range lists {
+			if baselineViews, ok := list[article]; ok {
+				total += baselineViews
+				listed++
+			} else {
+				total += floors[index]
+			}
+		}
+		baselineViews := total / len(lists)
+		if baselineViews == 0 {
+			baselineViews = 1
+		}
+		increase := float64(views) / float64(baselineViews)
+		minIncrease := trendingMinIncrease
+		if listed == len(lists) {
+			minIncrease = trendingEvergreenIncrease
+		}
+		if increase < minIncrease {
+			continue
+		}
+		pages = append(pages, TrendingPage{Title: article, Views: views, Baseline: baselineViews, Increase: increase})
+	}
+	sort.Slice(pages, func(i, j int) bool {
+		return pages[i].Increase > pages[j].Increase || (pages[i].Increase == pages[j].Increase && pages[i].Title < pages[j].Title)
+	})
+	return pages
+}
@@ -0,0 +1,31 @@
+package wikipedia
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_rankTrending(t *testing.T) {
+	day := map[string]int{
+		"Main_Page":  500000,
+		"Comet_X":    90000,
+		"Tokyo":      12000,
+		"Eclipse":    60000,
+		"Cat":        20000,
+		"New_Record": 8000,
+	}
+	baseline := []map[string]int{
+		{"Main_Page": 500000, "Eclipse": 10000, "Tokyo": 10000, "Cat": 4000, "Filler": 2000},
+		{"Main_Page": 500000, "Eclipse": 10000, "Tokyo": 10000, "Cat": 4000, "Filler": 2000},
+		nil,
+	}
+	expected := []string{"Comet_X", "Eclipse", "Cat", "New_Record"}
+
+	titles := []string{}
+	for _, page := range rankTrending(day, baseline) {
+		titles = append(titles, page.Title)
+	}
+	if !reflect.DeepEqual(titles, expected) {
+		t.Errorf("rankTrending() = %q, want %q", titles, expected)
+	}
+}
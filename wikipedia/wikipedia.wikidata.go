@@ -0,0 +1,411 @@
+package wikipedia
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var wikidataAPIendpoint = "https://www.wikidata.org/w/api.php"
+var wikidataEntityPath = "https://www.wikidata.org/wiki/%s"
+
+// The maximum number of ids in a single wbgetentities request
+const wikidataIDsLimit = 50
+
+// The maximum number of values shown for a single property
+const wikidataValuesLimit = 3
+
+// DefaultFactProperties are the Wikidata properties shown on the facts
+// card, by the item's "instance of" (P31) type. The "default" key is
+// used for items of any other type.
+var DefaultFactProperties = map[string][]string{
+	// Human: occupation, birth date and place, death date and place, citizenship
+	"Q5": {"P106", "P569", "P19", "P570", "P20", "P27", "P856"},
+	// City: country, located in, inception, population, area
+	"Q515": {"P17", "P131", "P571", "P1082", "P2046", "P856"},
+	// Country and sovereign state: capital, inception, population, area, currency
+	"Q6256":    {"P36", "P571", "P1082", "P2046", "P38", "P856"},
+	"Q3624078": {"P36", "P571", "P1082", "P2046", "P38", "P856"},
+	// Business: country, headquarters, inception, founder, CEO
+	"Q4830453": {"P17", "P159", "P571", "P112", "P169", "P856"},
+	// Film: director, publication date, cast, duration
+	"Q11424": {"P57", "P577", "P161", "P2047"},
+	// Literary work: author, publication date, language, genre
+	"Q7725634": {"P50", "P577", "P407", "P136"},
+	"default":  {"P571", "P17", "P1082", "P856"},
+}
+
+// Fact is the value of a single Wikidata property, with labels
+// localized to the requested language
+type Fact struct {
+	// Property is the Wikidata property ID, like "P569"
+	Property string
+	Label    string
+	Value    string
+}
+
+// FactsCard is a compact set of facts about a Wikidata item
+type FactsCard struct {
+	Item        string
+	Label       string
+	Description string
+	// Type is the label of the item's "instance of" value
+	Type  string
+	URL   string
+	Facts []Fact
+}
+
+// FetchFactsCard fetches the facts about the given Wikidata item, with
+// labels in the given language. The properties are picked by the item's
+// "instance of" type from the given map, which falls back on
+// DefaultFactProperties.
+func FetchFactsCard(item string, lang string, properties map[string][]string) (card FactsCard, found bool) {
+	if len(item) == 0 {
+		return card, false
+	}
+	entities := fetchWikidataEntities([]string{item}, "labels|descriptions|claims", lang)
+	entity, ok := entities[item]
+	if !ok || entity.Missing != nil {
+		return card, false
+	}
+
+	// Pick the properties by the first type that has a configured list
+	types := entityIDValues(entity.Claims["P31"])
+	selected := pickFactProperties(types, properties)
+
+	// Fetch the labels of every entity the card mentions at once
+	ids := append([]string{"P31"}, selected...)
+	if len(types) > 0 {
+		ids = append(ids, types[0])
+	}
+	for _, property := range selected {
+		for _, claim := range bestClaims(entity.Claims[property]) {
+			ids = append(ids, snakReferencedIDs(claim.Mainsnak)...)
+		}
+	}
+	labels := fetchWikidataLabels(ids, lang)
+
+	card = FactsCard{
+		Item:        item,
+		Label:       localizedText(entity.Labels, lang),
+		Description: localizedText(entity.Descriptions, lang),
+		URL:         fmt.Sprintf(wikidataEntityPath, item),
+	}
+	if len(types) > 0 {
+		card.Type = labelOf(types[0], labels)
+	}
+	for _, property := range selected {
+		values := []string{}
+		for _, claim := range bestClaims(entity.Claims[property]) {
+			if value := formatSnakValue(claim.Mainsnak, labels); len(value) != 0 {
+				values = append(values, value)
+			}
+		}
+		if len(values) == 0 {
+			continue
+		}
+		if len(values) > wikidataValuesLimit {
+			values = append(values[:wikidataValuesLimit], "...")
+		}
+		card.Facts = append(card.Facts, Fact{property, labelOf(property, labels), strings.Join(values, ", ")})
+	}
+	return card, true
+}
+
+// Output the properties for the first of the given types that has a list
+// in the configured properties, or in the defaults
+func pickFactProperties(types []string, properties map[string][]string) []string {
+	for _, source := range []map[string][]string{properties, DefaultFactProperties} {
+		for _, itemType := range types {
+			if selected, ok := source[itemType]; ok {
+				return selected
+			}
+		}
+	}
+	if selected, ok := properties["default"]; ok {
+		return selected
+	}
+	return DefaultFactProperties["default"]
+}
+
+// Fetch the given entities from Wikidata, in batches the API accepts
+func fetchWikidataEntities(ids []string, props string, lang string) (entities map[string]WikidataEntity) {
+	entities = map[string]WikidataEntity{}
+	ids = UniqueStrings(ids)
+	for start := 0; start < len(ids); start += wikidataIDsLimit {
+		end := start + wikidataIDsLimit
+		if end > len(ids) {
+			end = len(ids)
+		}
+
+		params := url.Values{}
+		params.Add("action", "wbgetentities")
+		params.Add("format", "json")
+		params.Add("ids", strings.Join(ids[start:end], "|"))
+		params.Add("props", props)
+		params.Add("languages", wikidataLanguages(lang))
+
+		url := wikidataAPIendpoint + "?" + params.Encode()
+		toLog("fetchWikidataEntities", "URL: "+url)
+
+		body, readErr := fetchFromAPI(url)
+		if readErr != nil {
+			continue
+		}
+		for id, entity := range processWikidataEntities(body) {
+			entities[id] = entity
+		}
+	}
+	return entities
+}
+
+// Fetch the labels of the given entities, in the given language if available
+func fetchWikidataLabels(ids []string, lang string) (labels map[string]string) {
+	labels = map[string]string{}
+	for id, entity := range fetchWikidataEntities(ids, "labels", lang) {
+		labels[id] = localizedText(entity.Labels, lang)
+	}
+	return labels
+}
+
+// Process the wbgetentities response into its entities
+func processWikidataEntities(body []byte) (entities map[string]WikidataEntity) {
+	record := WikidataEntitiesResponse{}
+	jsonErr := json.Unmarshal(body, &record)
+	if jsonErr != nil || len(record.Error.Code) != 0 {
+		if len(record.Error.Info) != 0 {
+			toLog("processWikidataEntities", "Error fetching. Details: "+record.Error.Info)
+		}
+		return map[string]WikidataEntity{}
+	}
+	return record.Entities
+}
+
+// Output the claims with the best rank: the preferred ones if there are
+// any, otherwise the normal ones. Deprecated claims are never used.
+func bestClaims(claims []WikidataClaim) []WikidataClaim {
+	preferred, normal := []WikidataClaim{}, []WikidataClaim{}
+	for _, claim := range claims {
+		switch claim.Rank {
+		case "preferred":
+			preferred = append(preferred, claim)
+		case "normal":
+			normal = append(normal, claim)
+		}
+	}
+	if len(preferred) > 0 {
+		return preferred
+	}
+	return normal
+}
+
+// Output the item IDs that the given claims point at
+func entityIDValues(claims []WikidataClaim) (ids []string) {
+	for _, claim := range bestClaims(claims) {
+		value := struct {
+			ID string `json:"id"`
+		}{}
+		if claim.Mainsnak.Datavalue.Type == "wikibase-entityid" && json.Unmarshal(claim.Mainsnak.Datavalue.Value, &value) == nil {
+			ids = append(ids, value.ID)
+		}
+	}
+	return ids
+}
+
+// Output the entity IDs whose labels are needed to display the snak:
+// its property, an item value, or the unit of a quantity
+func snakReferencedIDs(snak WikidataSnak) (ids []string) {
+	ids = []string{snak.Property}
+	switch snak.Datavalue.Type {
+	case "wikibase-entityid":
+		value := struct {
+			ID string `json:"id"`
+		}{}
+		if json.Unmarshal(snak.Datavalue.Value, &value) == nil {
+			ids = append(ids, value.ID)
+		}
+	case "quantity":
+		value := struct {
+			Unit string `json:"unit"`
+		}{}
+		if json.Unmarshal(snak.Datavalue.Value, &value) == nil && value.Unit != "1" {
+			ids = append(ids, wikidataEntityFromURI(value.Unit))
+		}
+	}
+	return ids
+}
+
+// Format the value of a snak for display, using the given labels
+// for any entities it points at
+func formatSnakValue(snak WikidataSnak, labels map[string]string) string {
+	switch snak.Snaktype {
+	case "somevalue":
+		return "unknown"
+	case "novalue":
+		return "none"
+	}
+
+	raw := snak.Datavalue.Value
+	switch snak.Datavalue.Type {
+	case "string":
+		value := ""
+		if json.Unmarshal(raw, &value) == nil {
+			return value
+		}
+	case "wikibase-entityid":
+		value := struct {
+			ID string `json:"id"`
+		}{}
+		if json.Unmarshal(raw, &value) == nil {
+			return labelOf(value.ID, labels)
+		}
+	case "monolingualtext":
+		value := struct {
+			Text string `json:"text"`
+		}{}
+		if json.Unmarshal(raw, &value) == nil {
+			return value.Text
+		}
+	case "quantity":
+		value := struct {
+			Amount string `json:"amount"`
+			Unit   string `json:"unit"`
+		}{}
+		if json.Unmarshal(raw, &value) == nil {
+			formatted := formatWikidataAmount(value.Amount)
+			if value.Unit != "1" && len(value.Unit) != 0 {
+				formatted += " " + labelOf(wikidataEntityFromURI(value.Unit), labels)
+			}
+			return formatted
+		}
+	case "time":
+		value := struct {
+			Time      string `json:"time"`
+			Precision int    `json:"precision"`
+		}{}
+		if json.Unmarshal(raw, &value) == nil {
+			return formatWikidataTime(value.Time, value.Precision)
+		}
+	case "globecoordinate":
+		value := struct {
+			Latitude  float64 `json:"latitude"`
+			Longitude float64 `json:"longitude"`
+		}{}
+		if json.Unmarshal(raw, &value) == nil {
+			return fmt.Sprintf("%.4f, %.4f", value.Latitude, value.Longitude)
+		}
+	}
+	return ""
+}
+
+// Format a Wikidata amount, like "+13960000", with thousands separators
+func formatWikidataAmount(amount string) string {
+	amount = strings.TrimPrefix(amount, "+")
+	sign := ""
+	if strings.HasPrefix(amount, "-") {
+		sign, amount = "-", amount[1:]
+	}
+	whole, fraction := amount, ""
+	if dot := strings.Index(amount, "."); dot >= 0 {
+		whole, fraction = amount[:dot], amount[dot:]
+	}
+	for i := len(whole) - 3; i > 0; i -= 3 {
+		whole = whole[:i] + "," + whole[i:]
+	}
+	return sign + whole + fraction
+}
+
+// Format a Wikidata time, like "+1952-03-11T00:00:00Z", to its precision.
+// Precision 11 is a day, 10 a month, 9 a year, and lower ones are decades,
+// centuries and millennia.
+func formatWikidataTime(value string, precision int) string {
+	era := ""
+	if strings.HasPrefix(value, "-") {
+		era = " BC"
+	}
+	parts := strings.SplitN(strings.TrimLeft(value, "+-"), "-", 3)
+	if len(parts) < 3 || len(parts[2]) < 2 {
+		return value
+	}
+	year, yearErr := strconv.Atoi(parts[0])
+	month, _ := strconv.Atoi(parts[1])
+	day, _ := strconv.Atoi(parts[2][:2])
+	if yearErr != nil {
+		return value
+	}
+
+	switch {
+	case precision >= 11 && month > 0 && day > 0:
+		return fmt.Sprintf("%d %s %d%s", day, time.Month(month), year, era)
+	case precision == 10 && month > 0:
+		return fmt.Sprintf("%s %d%s", time.Month(month), year, era)
+	case precision == 8:
+		return fmt.Sprintf("%ds%s", year/10*10, era)
+	case precision == 7:
+		return fmt.Sprintf("%s century%s", ordinal((year+99)/100), era)
+	case precision < 7:
+		return fmt.Sprintf("%s millennium%s", ordinal((year+999)/1000), era)
+	}
+	return fmt.Sprintf("%d%s", year, era)
+}
+
+// Output the English ordinal of the given number, like "21st"
+func ordinal(number int) string {
+	suffix := "th"
+	switch {
+	case number%100 >= 11 && number%100 <= 13:
+	case number%10 == 1:
+		suffix = "st"
+	case number%10 == 2:
+		suffix = "nd"
+	case number%10 == 3:
+		suffix = "rd"
+	}
+	return strconv.Itoa(number) + suffix
+}
+
+// Output the entity ID at the end of a Wikidata entity URI
+func wikidataEntityFromURI(uri string) string {
+	return uri[strings.LastIndex(uri, "/")+1:]
+}
+
+// Output the label of the given entity, or its ID if there is no label
+func labelOf(id string, labels map[string]string) string {
+	if label, ok := labels[id]; ok && len(label) != 0 {
+		return label
+	}
+	return id
+}
+
+// Output the text in the given language, falling back on English
+func localizedText(texts map[string]WikidataText, lang string) string {
+	if text, ok := texts[lang]; ok {
+		return text.Value
+	}
+	return texts["en"].Value
+}
+
+// Output the languages to request from Wikidata: the given one, with English as fallback
+func wikidataLanguages(lang string) string {
+	if len(lang) == 0 || lang == "en" {
+		return "en"
+	}
+	return lang + "|en"
+}
+
+// UniqueStrings outputs the given strings without duplicates or empty
+// strings, keeping their order
+func UniqueStrings(list []string) []string {
+	seen := map[string]bool{}
+	unique := []string{}
+	for _, item := range list {
+		if len(item) != 0 && !seen[item] {
+			seen[item] = true
+			unique = append(unique, item)
+		}
+	}
+	return unique
+}
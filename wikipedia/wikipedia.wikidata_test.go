@@ -0,0 +1,78 @@
+package wikipedia
+
+import (
+	"testing"
+)
+
+func Test_formatSnakValue(t *testing.T) {
+	entities := processWikidataEntities([]byte(`{"entities":{"Q1490":{"id":"Q1490","claims":{
+		"P1082":[{"mainsnak":{"snaktype":"value","property":"P1082","datavalue":{"value":{"amount":"+13960000","unit":"1"},"type":"quantity"}},"rank":"preferred"}],
+		"P2046":[{"mainsnak":{"snaktype":"value","property":"P2046","datavalue":{"value":{"amount":"+2194.07","unit":"http://www.wikidata.org/entity/Q712226"},"type":"quantity"}},"rank":"normal"}],
+		"P571":[{"mainsnak":{"snaktype":"value","property":"P571","datavalue":{"value":{"time":"+1457-00-00T00:00:00Z","precision":9},"type":"time"}},"rank":"normal"}],
+		"P17":[{"mainsnak":{"snaktype":"value","property":"P17","datavalue":{"value":{"entity-type":"item","id":"Q17"},"type":"wikibase-entityid"}},"rank":"normal"},{"mainsnak":{"snaktype":"value","property":"P17","datavalue":{"value":{"entity-type":"item","id":"Q188712"},"type":"wikibase-entityid"}},"rank":"deprecated"}],
+		"P856":[{"mainsnak":{"snaktype":"value","property":"P856","datavalue":{"value":"https://www.metro.tokyo.lg.jp","type":"string"}},"rank":"normal"}],
+		"P1448":[{"mainsnak":{"snaktype":"value","property":"P1448","datavalue":{"value":{"text":"東京都","language":"ja"},"type":"monolingualtext"}},"rank":"normal"}],
+		"P36":[{"mainsnak":{"snaktype":"somevalue","property":"P36"},"rank":"normal"}]
+	}}}}`))
+	labels := map[string]string{"Q17": "Japan", "Q712226": "square kilometre"}
+
+	tests := []struct {
+		property string
+		expected string
+	}{
+		{"P1082", "13,960,000"},
+		{"P2046", "2,194.07 square kilometre"},
+		{"P571", "1457"},
+		{"P17", "Japan"},
+		{"P856", "https://www.metro.tokyo.lg.jp"},
+		{"P1448", "東京都"},
+		{"P36", "unknown"},
+	}
+	claims := entities["Q1490"].Claims
+	for _, tt := range tests {
+		t.Run(tt.property, func(t *testing.T) {
+			best := bestClaims(claims[tt.property])
+			if len(best) != 1 {
+				t.Fatalf("bestClaims() returned %d claims, want 1", len(best))
+			}
+			if value := formatSnakValue(best[0].Mainsnak, labels); value != tt.expected {
+				t.Errorf("formatSnakValue() = %q, want %q", value, tt.expected)
+			}
+		})
+	}
+}
+
+func Test_formatWikidataTime(t *testing.T) {
+	tests := []struct {
+		time      string
+		precision int
+		expected  string
+	}{
+		{"+1815-12-10T00:00:00Z", 11, "10 December 1815"},
+		{"+1815-12-00T00:00:00Z", 10, "December 1815"},
+		{"+1815-00-00T00:00:00Z", 9, "1815"},
+		{"+1815-00-00T00:00:00Z", 8, "1810s"},
+		{"+1801-00-00T00:00:00Z", 7, "19th century"},
+		{"-0044-03-15T00:00:00Z", 11, "15 March 44 BC"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.time, func(t *testing.T) {
+			if formatted := formatWikidataTime(tt.time, tt.precision); formatted != tt.expected {
+				t.Errorf("formatWikidataTime() = %q, want %q", formatted, tt.expected)
+			}
+		})
+	}
+}
+
+func Test_pickFactProperties(t *testing.T) {
+	configured := map[string][]string{"Q515": {"P1082"}, "default": {"P856"}}
+	if selected := pickFactProperties([]string{"Q515"}, configured); len(selected) != 1 || selected[0] != "P1082" {
+		t.Errorf("pickFactProperties() = %v, want the configured city properties", selected)
+	}
+	if selected := pickFactProperties([]string{"Q5"}, configured); len(selected) != len(DefaultFactProperties["Q5"]) {
+		t.Errorf("pickFactProperties() = %v, want the default human properties", selected)
+	}
+	if selected := pickFactProperties([]string{"Q42"}, configured); len(selected) != 1 || selected[0] != "P856" {
+		t.Errorf("pickFactProperties() = %v, want the configured default properties", selected)
+	}
+}
@@ -0,0 +1,565 @@
+package wikipedia
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+)
+
+// ZIM file format constants.
+// See https://wiki.openzim.org/wiki/ZIM_file_format
+const (
+	zimMagicNumber     = 72173914
+	zimHeaderSize      = 80
+	zimMimeRedirect    = 0xffff
+	zimMimeLinkTarget  = 0xfffe
+	zimMimeDeleted     = 0xfffd
+	zimMaxRedirects    = 5
+	zimSearchLimit     = 5
+	zimSearchExtractSz = 250
+)
+
+// ZIM is a Backend that reads articles from a local Kiwix ZIM archive,
+// so the bot can answer without any network access.
+type ZIM struct {
+	DisplayName string
+	Lang        string
+	// ArticlePath is the URL of an article, with %s in place of the title,
+	// for example on a local kiwix-serve. Leave empty for no links.
+	ArticlePath string
+
+	file          *os.File
+	articleCount  uint32
+	clusterCount  uint32
+	urlPtrPos     uint64
+	titlePtrPos   uint64
+	clusterPtrPos uint64
+	checksumPos   uint64
+	mimeTypes     []string
+	// Namespace of the articles; "A" in older archives, "C" in newer ones
+	articleNamespace byte
+
+	// The most recently decompressed cluster, since consecutive
+	// lookups often land in the same one
+	cacheLock    sync.Mutex
+	cacheCluster uint32
+	cacheBlobs   [][]byte
+}
+
+// zimEntry is a directory entry of the ZIM archive
+type zimEntry struct {
+	mimeType      uint16
+	namespace     byte
+	clusterNumber uint32
+	blobNumber    uint32
+	redirectIndex uint32
+	url           string
+	title         string
+}
+
+// OpenZIM opens the ZIM archive at the given path
+func OpenZIM(path string, name string, lang string) (archive *ZIM, err error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	archive = &ZIM{DisplayName: name, Lang: lang, file: file, cacheCluster: ^uint32(0)}
+	if err := archive.readHeader(); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return archive, nil
+}
+
+// Close closes the underlying archive file
+func (z *ZIM) Close() error {
+	return z.file.Close()
+}
+
+// Name outputs the human readable name of the archive
+func (z *ZIM) Name() string {
+	if len(z.DisplayName) == 0 {
+		return z.file.Name()
+	}
+	return z.DisplayName
+}
+
+// Language outputs the content language of the archive, defaulting to "en"
+func (z *ZIM) Language() string {
+	if len(z.Lang) == 0 {
+		return "en"
+	}
+	return z.Lang
+}
+
+// Supports reports whether the archive offers the given feature.
+// Archives have no related pages or analytics.
+func (z *ZIM) Supports(feature Feature) bool {
+	return false
+}
+
+// Summary outputs the lead section of the article with the given title,
+// following redirects
+func (z *ZIM) Summary(title string) []Page {
+	index, found := z.findArticle(strings.TrimSpace(title))
+	if !found {
+		return getNotFound()
+	}
+	page, err := z.readPage(index, 0)
+	if err != nil {
+		toLog("ZIM Summary", err.Error())
+		return getNotFound()
+	}
+	return []Page{page}
+}
+
+// Related is not available for archives
+func (z *ZIM) Related(title string) []Page {
+	return getNotFound()
+}
+
+// Search outputs the articles whose title starts with the given term
+func (z *ZIM) Search(term string) []Page {
+	term = strings.TrimSpace(term)
+	if len(term) == 0 {
+		return getNotFound()
+	}
+
+	collection := []Page{}
+	seen := map[string]bool{}
+	for _, prefix := range titleVariants(term) {
+		for _, index := range z.prefixSearch(prefix, zimSearchLimit) {
+			if len(collection) >= zimSearchLimit {
+				break
+			}
+			page, err := z.readPage(index, zimSearchExtractSz)
+			if err != nil || seen[page.Title] {
+				continue
+			}
+			seen[page.Title] = true
+			page.Rank = len(collection) + 1
+			collection = append(collection, page)
+		}
+	}
+
+	if len(collection) == 0 {
+		return getNotFound()
+	}
+	return collection
+}
+
+// Read and validate the archive header and the mime type list
+func (z *ZIM) readHeader() error {
+	header := make([]byte, zimHeaderSize)
+	if _, err := z.file.ReadAt(header, 0); err != nil {
+		return err
+	}
+	if binary.LittleEndian.Uint32(header[0:4]) != zimMagicNumber {
+		return errors.New("not a ZIM archive")
+	}
+	z.articleCount = binary.LittleEndian.Uint32(header[24:28])
+	z.clusterCount = binary.LittleEndian.Uint32(header[28:32])
+	z.urlPtrPos = binary.LittleEndian.Uint64(header[32:40])
+	z.titlePtrPos = binary.LittleEndian.Uint64(header[40:48])
+	z.clusterPtrPos = binary.LittleEndian.Uint64(header[48:56])
+	mimeListPos := binary.LittleEndian.Uint64(header[56:64])
+	z.checksumPos = binary.LittleEndian.Uint64(header[72:80])
+
+	// The mime type list is a list of zero-terminated strings,
+	// ending with an empty string
+	reader := io.NewSectionReader(z.file, int64(mimeListPos), int64(z.urlPtrPos-mimeListPos))
+	list, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return err
+	}
+	for _, mime := range bytes.Split(list, []byte{0}) {
+		if len(mime) == 0 {
+			break
+		}
+		z.mimeTypes = append(z.mimeTypes, string(mime))
+	}
+
+	z.articleNamespace = 'A'
+	if !z.hasNamespace('A') && z.hasNamespace('C') {
+		z.articleNamespace = 'C'
+	}
+	return nil
+}
+
+// Whether any entry lives in the given namespace
+func (z *ZIM) hasNamespace(namespace byte) bool {
+	index := sort.Search(int(z.articleCount), func(i int) bool {
+		entry, err := z.entryAtURLIndex(uint32(i))
+		return err != nil || entry.namespace >= namespace
+	})
+	if index >= int(z.articleCount) {
+		return false
+	}
+	entry, err := z.entryAtURLIndex(uint32(index))
+	return err == nil && entry.namespace == namespace
+}
+
+// Find the article for the given title, trying the url and title
+// indexes with the common capitalizations of the title
+func (z *ZIM) findArticle(title string) (index uint32, found bool) {
+	for _, variant := range titleVariants(title) {
+		if index, found := z.searchURL(z.articleNamespace, strings.ReplaceAll(variant, " ", "_")); found {
+			return index, true
+		}
+		if index, found := z.searchTitle(z.articleNamespace, variant); found {
+			return index, true
+		}
+	}
+	return 0, false
+}
+
+// Binary search the url pointer list for the given namespace and url
+func (z *ZIM) searchURL(namespace byte, url string) (index uint32, found bool) {
+	i := sort.Search(int(z.articleCount), func(i int) bool {
+		entry, err := z.entryAtURLIndex(uint32(i))
+		return err != nil || compareEntry(entry.namespace, entry.url, namespace, url) >= 0
+	})
+	if i >= int(z.articleCount) {
+		return 0, false
+	}
+	entry, err := z.entryAtURLIndex(uint32(i))
+	if err != nil || entry.namespace != namespace || entry.url != url {
+		return 0, false
+	}
+	return uint32(i), true
+}
+
+// Binary search the title pointer list for the given namespace and title
+func (z *ZIM) searchTitle(namespace byte, title string) (index uint32, found bool) {
+	i := z.titleLowerBound(namespace, title)
+	if i >= int(z.articleCount) {
+		return 0, false
+	}
+	urlIndex, entry, err := z.entryAtTitleIndex(uint32(i))
+	if err != nil || entry.namespace != namespace || entry.sortTitle() != title {
+		return 0, false
+	}
+	return urlIndex, true
+}
+
+// Output up to limit url indexes of the articles whose title starts with the given prefix
+func (z *ZIM) prefixSearch(prefix string, limit int) (indexes []uint32) {
+	for i := z.titleLowerBound(z.articleNamespace, prefix); i < int(z.articleCount) && len(indexes) < limit; i++ {
+		urlIndex, entry, err := z.entryAtTitleIndex(uint32(i))
+		if err != nil || entry.namespace != z.articleNamespace || !strings.HasPrefix(entry.sortTitle(), prefix) {
+			break
+		}
+		indexes = append(indexes, urlIndex)
+	}
+	return indexes
+}
+
+// Output the position of the first entry in the title pointer list that
+// is not lower than the given namespace and title
+func (z *ZIM) titleLowerBound(namespace byte, title string) int {
+	return sort.Search(int(z.articleCount), func(i int) bool {
+		_, entry, err := z.entryAtTitleIndex(uint32(i))
+		return err != nil || compareEntry(entry.namespace, entry.sortTitle(), namespace, title) >= 0
+	})
+}
+
+// Read the article at the given url index into a Page, following redirects.
+// A positive extractLength cuts the extract to about that many characters.
+func (z *ZIM) readPage(index uint32, extractLength int) (page Page, err error) {
+	entry, err := z.entryAtURLIndex(index)
+	for redirects := 0; err == nil && entry.mimeType == zimMimeRedirect; redirects++ {
+		if redirects >= zimMaxRedirects {
+			return page, errors.New("too many redirects for " + entry.url)
+		}
+		entry, err = z.entryAtURLIndex(entry.redirectIndex)
+	}
+	if err != nil {
+		return page, err
+	}
+	if entry.mimeType == zimMimeLinkTarget || entry.mimeType == zimMimeDeleted {
+		return page, errors.New("no content for " + entry.url)
+	}
+	if int(entry.mimeType) >= len(z.mimeTypes) || !strings.HasPrefix(z.mimeTypes[entry.mimeType], "text/html") {
+		return page, errors.New("not an article: " + entry.url)
+	}
+
+	content, err := z.readBlob(entry.clusterNumber, entry.blobNumber)
+	if err != nil {
+		return page, err
+	}
+
+	extract := extractLeadSection(string(content))
+	if extractLength > 0 && len(extract) > extractLength {
+		extract = truncateAtWord(extract, extractLength) + "..."
+	}
+
+	url := ""
+	if len(z.ArticlePath) != 0 {
+		url = zimArticleURL(z.ArticlePath, entry.url)
+	}
+	return Page{Title: entry.displayTitle(), Extract: extract, URL: url}, nil
+}
+
+// Output the link to an entry of the archive, with each segment of its
+// path escaped, since entry paths can have spaces, "?" or "#" in them
+func zimArticleURL(articlePath string, entryURL string) string {
+	segments := strings.Split(entryURL, "/")
+	for index, segment := range segments {
+		segments[index] = url.PathEscape(segment)
+	}
+	return fmt.Sprintf(articlePath, strings.Join(segments, "/"))
+}
+
+// Read the directory entry at the given position of the url pointer list
+func (z *ZIM) entryAtURLIndex(index uint32) (entry zimEntry, err error) {
+	if index >= z.articleCount {
+		return entry, errors.New("entry index out of range")
+	}
+	pointer := make([]byte, 8)
+	if _, err := z.file.ReadAt(pointer, int64(z.urlPtrPos)+int64(index)*8); err != nil {
+		return entry, err
+	}
+	return z.readEntry(binary.LittleEndian.Uint64(pointer))
+}
+
+// Read the directory entry at the given position of the title pointer list,
+// and output its position in the url pointer list
+func (z *ZIM) entryAtTitleIndex(index uint32) (urlIndex uint32, entry zimEntry, err error) {
+	pointer := make([]byte, 4)
+	if _, err := z.file.ReadAt(pointer, int64(z.titlePtrPos)+int64(index)*4); err != nil {
+		return 0, entry, err
+	}
+	urlIndex = binary.LittleEndian.Uint32(pointer)
+	entry, err = z.entryAtURLIndex(urlIndex)
+	return urlIndex, entry, err
+}
+
+// Read the directory entry at the given file offset
+func (z *ZIM) readEntry(offset uint64) (entry zimEntry, err error) {
+	// The fixed part of the entry is followed by two zero-terminated
+	// strings; titles are rarely longer than this
+	buffer := make([]byte, 1024)
+	read, err := z.file.ReadAt(buffer, int64(offset))
+	if err != nil && err != io.EOF {
+		return entry, err
+	}
+	buffer = buffer[:read]
+	if len(buffer) < 16 {
+		return entry, errors.New("truncated directory entry")
+	}
+
+	entry.mimeType = binary.LittleEndian.Uint16(buffer[0:2])
+	entry.namespace = buffer[3]
+	names := buffer[16:]
+	if entry.mimeType == zimMimeRedirect {
+		entry.redirectIndex = binary.LittleEndian.Uint32(buffer[8:12])
+		names = buffer[12:]
+	} else {
+		entry.clusterNumber = binary.LittleEndian.Uint32(buffer[8:12])
+		entry.blobNumber = binary.LittleEndian.Uint32(buffer[12:16])
+	}
+
+	parts := bytes.SplitN(names, []byte{0}, 3)
+	if len(parts) < 3 {
+		return entry, errors.New("truncated directory entry strings")
+	}
+	entry.url = string(parts[0])
+	entry.title = string(parts[1])
+	return entry, nil
+}
+
+// Read the given blob out of the given cluster
+func (z *ZIM) readBlob(clusterNumber uint32, blobNumber uint32) (blob []byte, err error) {
+	z.cacheLock.Lock()
+	defer z.cacheLock.Unlock()
+
+	if z.cacheCluster != clusterNumber {
+		blobs, err := z.readCluster(clusterNumber)
+		if err != nil {
+			return nil, err
+		}
+		z.cacheCluster = clusterNumber
+		z.cacheBlobs = blobs
+	}
+	if int(blobNumber) >= len(z.cacheBlobs) {
+		return nil, errors.New("blob number out of range")
+	}
+	return z.cacheBlobs[blobNumber], nil
+}
+
+// Read and decompress the given cluster into its blobs
+func (z *ZIM) readCluster(clusterNumber uint32) (blobs [][]byte, err error) {
+	if clusterNumber >= z.clusterCount {
+		return nil, errors.New("cluster number out of range")
+	}
+	pointers := make([]byte, 16)
+	read, err := z.file.ReadAt(pointers, int64(z.clusterPtrPos)+int64(clusterNumber)*8)
+	if err != nil && read < 8 {
+		return nil, err
+	}
+	start := binary.LittleEndian.Uint64(pointers[0:8])
+	// The last cluster ends where the checksum starts
+	end := z.checksumPos
+	if clusterNumber+1 < z.clusterCount {
+		end = binary.LittleEndian.Uint64(pointers[8:16])
+	}
+	if end <= start {
+		return nil, errors.New("invalid cluster boundaries")
+	}
+
+	info := make([]byte, 1)
+	if _, err := z.file.ReadAt(info, int64(start)); err != nil {
+		return nil, err
+	}
+	section := io.NewSectionReader(z.file, int64(start)+1, int64(end-start-1))
+
+	var reader io.Reader
+	switch info[0] & 0x0f {
+	case 0, 1:
+		reader = section
+	case 4:
+		reader, err = xz.NewReader(section)
+	case 5:
+		decoder, decoderErr := zstd.NewReader(section)
+		if decoderErr == nil {
+			defer decoder.Close()
+		}
+		reader, err = decoder, decoderErr
+	default:
+		err = fmt.Errorf("unsupported cluster compression %d", info[0]&0x0f)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	return splitClusterBlobs(data, info[0]&0x10 != 0)
+}
+
+// Split the decompressed cluster data into blobs by the offset list
+// at its start. Extended clusters use 64 bit offsets.
+func splitClusterBlobs(data []byte, extended bool) (blobs [][]byte, err error) {
+	offsetSize := 4
+	if extended {
+		offsetSize = 8
+	}
+	readOffset := func(i int) uint64 {
+		if extended {
+			return binary.LittleEndian.Uint64(data[i*8:])
+		}
+		return uint64(binary.LittleEndian.Uint32(data[i*4:]))
+	}
+
+	if len(data) < offsetSize {
+		return nil, errors.New("empty cluster")
+	}
+	count := int(readOffset(0)) / offsetSize
+	if count < 1 || count*offsetSize > len(data) {
+		return nil, errors.New("invalid cluster offsets")
+	}
+	for i := 0; i < count-1; i++ {
+		start, end := readOffset(i), readOffset(i+1)
+		if start > end || end > uint64(len(data)) {
+			return nil, errors.New("invalid blob offsets")
+		}
+		blobs = append(blobs, data[start:end])
+	}
+	return blobs, nil
+}
+
+// Output the title the title index is sorted by, which defaults to the url
+func (entry zimEntry) sortTitle() string {
+	if len(entry.title) == 0 {
+		return entry.url
+	}
+	return entry.title
+}
+
+// Output the title of the entry for display
+func (entry zimEntry) displayTitle() string {
+	return strings.ReplaceAll(entry.sortTitle(), "_", " ")
+}
+
+// Compare two entries by namespace and then by url or title, in the
+// same byte order the archive indexes are sorted by
+func compareEntry(namespaceA byte, keyA string, namespaceB byte, keyB string) int {
+	if namespaceA != namespaceB {
+		if namespaceA < namespaceB {
+			return -1
+		}
+		return 1
+	}
+	return strings.Compare(keyA, keyB)
+}
+
+// Output the given title as-is and with a capitalized first letter,
+// which is how wikis store most titles
+func titleVariants(title string) []string {
+	variants := []string{title}
+	if first, size := utf8.DecodeRuneInString(title); size > 0 {
+		capitalized := string(unicode.ToUpper(first)) + title[size:]
+		if capitalized != title {
+			variants = append(variants, capitalized)
+		}
+	}
+	return variants
+}
+
+// Regular expressions for extracting the lead section out of article HTML
+var leadEndRegexp = regexp.MustCompile(`(?is)<h2[\s>]`)
+var paragraphRegexp = regexp.MustCompile(`(?is)<p[\s>].*?</p>`)
+var referenceRegexp = regexp.MustCompile(`(?is)<sup[^>]*class="[^"]*reference[^"]*"[^>]*>.*?</sup>`)
+var tagRegexp = regexp.MustCompile(`(?s)<[^>]*>`)
+var whitespaceRegexp = regexp.MustCompile(`\s+`)
+
+// Output the plain text of the paragraphs before the first heading of the article
+func extractLeadSection(articleHTML string) string {
+	if location := leadEndRegexp.FindStringIndex(articleHTML); location != nil {
+		articleHTML = articleHTML[:location[0]]
+	}
+
+	paragraphs := []string{}
+	for _, paragraph := range paragraphRegexp.FindAllString(articleHTML, -1) {
+		text := referenceRegexp.ReplaceAllString(paragraph, "")
+		text = html.UnescapeString(tagRegexp.ReplaceAllString(text, ""))
+		text = strings.TrimSpace(whitespaceRegexp.ReplaceAllString(text, " "))
+		if len(text) != 0 {
+			paragraphs = append(paragraphs, text)
+		}
+	}
+	return strings.Join(paragraphs, "\n")
+}
+
+// Cut the given text to at most the given length, at a word boundary
+func truncateAtWord(text string, length int) string {
+	if len(text) <= length {
+		return text
+	}
+	for length > 0 && !utf8.RuneStart(text[length]) {
+		length--
+	}
+	cut := text[:length]
+	if space := strings.LastIndexAny(cut, " \n"); space > 0 {
+		cut = cut[:space]
+	}
+	return strings.TrimSpace(cut)
+}
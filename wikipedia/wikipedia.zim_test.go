@@ -0,0 +1,158 @@
+package wikipedia
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// Write a small uncompressed ZIM archive with two articles and a redirect
+func writeTestZIM(t *testing.T) string {
+	blobs := [][]byte{
+		[]byte(`<html><body><p><b>Paris</b> is the capital of France.<sup class="reference">[1]</sup></p><p>It is &quot;big&quot;.</p><h2>History</h2><p>Old.</p></body></html>`),
+		[]byte(`<p>A <i>parrot</i> is a bird.</p>`),
+	}
+	entry := func(mime uint16, extra []byte, url string, title string) []byte {
+		buffer := &bytes.Buffer{}
+		binary.Write(buffer, binary.LittleEndian, mime)
+		buffer.Write([]byte{0, 'A', 0, 0, 0, 0})
+		buffer.Write(extra)
+		buffer.WriteString(url + "\x00" + title + "\x00")
+		return buffer.Bytes()
+	}
+	content := func(cluster uint32, blob uint32) []byte {
+		extra := make([]byte, 8)
+		binary.LittleEndian.PutUint32(extra[0:4], cluster)
+		binary.LittleEndian.PutUint32(extra[4:8], blob)
+		return extra
+	}
+	redirect := make([]byte, 4) // to url index 0
+	entries := [][]byte{
+		entry(0, content(0, 0), "Paris", ""),
+		entry(zimMimeRedirect, redirect, "Paris_France", "Paris France"),
+		entry(0, content(0, 1), "Parrot", ""),
+	}
+
+	// Uncompressed cluster: offsets list, then blobs
+	cluster := &bytes.Buffer{}
+	cluster.WriteByte(1)
+	offset := uint32(4 * (len(blobs) + 1))
+	for _, blob := range blobs {
+		binary.Write(cluster, binary.LittleEndian, offset)
+		offset += uint32(len(blob))
+	}
+	binary.Write(cluster, binary.LittleEndian, offset)
+	for _, blob := range blobs {
+		cluster.Write(blob)
+	}
+
+	mimeList := []byte("text/html\x00\x00")
+	mimeListPos := uint64(zimHeaderSize)
+	urlPtrPos := mimeListPos + uint64(len(mimeList))
+	titlePtrPos := urlPtrPos + uint64(8*len(entries))
+	clusterPtrPos := titlePtrPos + uint64(4*len(entries))
+	entriesPos := clusterPtrPos + 8
+
+	body := &bytes.Buffer{}
+	header := make([]byte, zimHeaderSize)
+	binary.LittleEndian.PutUint32(header[0:4], zimMagicNumber)
+	binary.LittleEndian.PutUint32(header[24:28], uint32(len(entries)))
+	binary.LittleEndian.PutUint32(header[28:32], 1)
+	binary.LittleEndian.PutUint64(header[32:40], urlPtrPos)
+	binary.LittleEndian.PutUint64(header[40:48], titlePtrPos)
+	binary.LittleEndian.PutUint64(header[48:56], clusterPtrPos)
+	binary.LittleEndian.PutUint64(header[56:64], mimeListPos)
+	body.Write(header)
+	body.Write(mimeList)
+	position := entriesPos
+	for _, e := range entries {
+		binary.Write(body, binary.LittleEndian, position)
+		position += uint64(len(e))
+	}
+	for _, index := range []uint32{0, 1, 2} {
+		binary.Write(body, binary.LittleEndian, index)
+	}
+	binary.Write(body, binary.LittleEndian, position)
+	for _, e := range entries {
+		body.Write(e)
+	}
+	body.Write(cluster.Bytes())
+	checksumPos := uint64(body.Len())
+	body.Write(make([]byte, 16))
+
+	data := body.Bytes()
+	binary.LittleEndian.PutUint64(data[72:80], checksumPos)
+
+	dir, err := ioutil.TempDir("", "zim")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "test.zim")
+	if err := ioutil.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func Test_ZIM(t *testing.T) {
+	path := writeTestZIM(t)
+	defer os.RemoveAll(filepath.Dir(path))
+
+	archive, err := OpenZIM(path, "Offline", "en")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer archive.Close()
+	archive.ArticlePath = "http://kiwix.local/A/%s"
+
+	paris := Page{Title: "Paris", Extract: "Paris is the capital of France.\nIt is \"big\".", URL: "http://kiwix.local/A/Paris"}
+	tests := []struct {
+		name     string
+		result   []Page
+		expected []Page
+	}{
+		{"Summary by url", archive.Summary("Paris"), []Page{paris}},
+		{"Summary with lowercase first letter", archive.Summary("paris"), []Page{paris}},
+		{"Summary through redirect", archive.Summary("Paris France"), []Page{paris}},
+		{"Summary not found", archive.Summary("London"), getNotFound()},
+		{
+			"Prefix search",
+			archive.Search("par"),
+			[]Page{
+				{Title: "Paris", Extract: paris.Extract, URL: paris.URL, Rank: 1},
+				{Title: "Parrot", Extract: "A parrot is a bird.", URL: "http://kiwix.local/A/Parrot", Rank: 2},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !reflect.DeepEqual(tt.result, tt.expected) {
+				t.Errorf("got %v\nExpected:\n %v", tt.result, tt.expected)
+			}
+		})
+	}
+}
+
+func Test_zimArticleURL(t *testing.T) {
+	tests := []struct {
+		name     string
+		entryURL string
+		expected string
+	}{
+		{"Plain title", "Paris", "http://kiwix.local/A/Paris"},
+		{"Spaces and punctuation", "What? Me worry #1", "http://kiwix.local/A/What%3F%20Me%20worry%20%231"},
+		{"Non-ASCII", "Zürich", "http://kiwix.local/A/Z%C3%BCrich"},
+		{"Subpage", "AC/DC", "http://kiwix.local/A/AC/DC"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := zimArticleURL("http://kiwix.local/A/%s", tt.entryURL); got != tt.expected {
+				t.Errorf("zimArticleURL() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
@@ -5,6 +5,47 @@ import (
 	"testing"
 )
 
+func Test_ParseLanguagesFromText(t *testing.T) {
+	tests := []struct {
+		name          string
+		text          string
+		expectedLangs []string
+		expectedText  string
+	}{
+		{"No language", "Paris", []string{"en"}, "Paris"},
+		{"Single language", "Paris lang=fr", []string{"fr"}, "Paris"},
+		{"Several languages", "Paris lang=en,fr,de", []string{"en", "fr", "de"}, "Paris"},
+		{"Empty language list", "Paris lang=,", []string{"en"}, "Paris"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			langs, text := ParseLanguagesFromText(tt.text)
+			if !reflect.DeepEqual(langs, tt.expectedLangs) || text != tt.expectedText {
+				t.Errorf("ParseLanguagesFromText() = %v, %q, want %v, %q", langs, text, tt.expectedLangs, tt.expectedText)
+			}
+		})
+	}
+}
+
+func Test_StripParameters(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		expected string
+	}{
+		{"No parameters", "Paris", "Paris"},
+		{"All parameters", "Paris lang=en,fr wiki=corp fallback=none", "Paris"},
+		{"Parameters in the middle", "New lang=fr York", "New York"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if text := StripParameters(tt.text); text != tt.expected {
+				t.Errorf("StripParameters() = %q, want %q", text, tt.expected)
+			}
+		})
+	}
+}
+
 func Test_prepTitleForURLQuery(t *testing.T) {
 	type args struct {
 		text string